@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/antigravity/finance-tracker/config"
 	"github.com/antigravity/finance-tracker/controllers"
@@ -23,22 +25,95 @@ func init() {
 	userRepo := repositories.NewUserRepository(config.DB)
 	catRepo := repositories.NewCategoryRepository(config.DB)
 	transRepo := repositories.NewTransactionRepository(config.DB)
+	budgetRepo := repositories.NewBudgetRepository(config.DB)
+	alertRepo := repositories.NewAlertRepository(config.DB)
+	ruleRepo := repositories.NewRuleRepository(config.DB)
+	payeeRepo := repositories.NewPayeeRepository(config.DB)
+	notifRepo := repositories.NewNotificationRepository(config.DB)
+	pushRepo := repositories.NewPushRepository(config.DB)
+	syncRepo := repositories.NewSyncRepository(config.DB)
+	trashRepo := repositories.NewTrashRepository(config.DB)
+	auditRepo := repositories.NewAuditRepository(config.DB)
+	telegramRepo := repositories.NewTelegramRepository(config.DB)
+	receiptRepo := repositories.NewReceiptRepository(config.DB)
+	duplicateRepo := repositories.NewDuplicateRepository(config.DB)
+	templateRepo := repositories.NewTemplateRepository(config.DB)
+	billRepo := repositories.NewBillRepository(config.DB)
+	calendarRepo := repositories.NewCalendarRepository(config.DB)
+	accountRepo := repositories.NewAccountRepository(config.DB)
+	reconRepo := repositories.NewReconciliationRepository(config.DB)
+
+	// Initialize Notifiers
+	pushService, err := services.NewPushService(pushRepo)
+	if err != nil {
+		log.Fatalf("Failed to initialize web push: %v", err)
+	}
+	notifiers := []services.Notifier{services.NewWebhookNotifier(), services.NewWebPushNotifier(pushService)}
+	if email := services.NewEmailNotifierFromEnv(); email != nil {
+		notifiers = append(notifiers, email)
+	}
 
 	// Initialize Services
 	authService := services.NewAuthService(userRepo)
-	catService := services.NewCategoryService(catRepo)
-	transService := services.NewTransactionService(transRepo)
+	auditService := services.NewAuditService(auditRepo)
+	catService := services.NewCategoryService(catRepo, auditService)
+	accountService := services.NewAccountService(accountRepo)
+	reconService := services.NewReconciliationService(reconRepo, accountService, auditService)
+	alertService := services.NewAlertService(alertRepo, notifRepo, transRepo, budgetRepo, userRepo, notifiers...)
+	payeeService := services.NewPayeeService(payeeRepo, catRepo, auditService)
+	ruleService := services.NewRuleService(ruleRepo, transRepo, catRepo, payeeService, accountService, auditService)
+	duplicateService := services.NewDuplicateService(duplicateRepo)
+	suggestionService := services.NewSuggestionService(transRepo, catRepo)
+	transService := services.NewTransactionService(transRepo, catRepo, alertService, ruleService, suggestionService, payeeService, duplicateService)
+	budgetService := services.NewBudgetService(budgetRepo, transRepo, userRepo, catRepo)
+	syncService := services.NewSyncService(syncRepo, catRepo, catService, transService, auditService)
+	trashService := services.NewTrashService(trashRepo, catService)
+	templateService := services.NewTemplateService(templateRepo, transService, catService, accountService)
+	billService := services.NewBillService(billRepo, payeeService, catService, accountService, alertService)
+	subscriptionService := services.NewSubscriptionService(transRepo, billRepo, billService, payeeService)
+	calendarService := services.NewCalendarService(calendarRepo, billRepo, billService, subscriptionService)
+	receiptService := services.NewReceiptService(receiptRepo, userRepo, transService, auditService)
+	telegramService := services.NewTelegramServiceFromEnv(telegramRepo, transService, budgetService, auditService)
 
 	// Initialize Controllers
 	authCtrl := controllers.NewAuthController(authService)
-	catCtrl := controllers.NewCategoryController(catService)
-	transCtrl := controllers.NewTransactionController(transService, catService)
+	catCtrl := controllers.NewCategoryController(catService, auditService)
+	transCtrl := controllers.NewTransactionController(transService, catService, accountService, auditService)
+	budgetCtrl := controllers.NewBudgetController(budgetService, auditService)
+	alertCtrl := controllers.NewAlertController(alertService)
+	ruleCtrl := controllers.NewRuleController(ruleService)
+	payeeCtrl := controllers.NewPayeeController(payeeService)
+	pushCtrl := controllers.NewPushController(pushService)
+	syncCtrl := controllers.NewSyncController(syncService)
+	trashCtrl := controllers.NewTrashController(trashService, auditService)
+	auditCtrl := controllers.NewAuditController(auditService)
+	telegramCtrl := controllers.NewTelegramController(telegramService)
+	receiptCtrl := controllers.NewReceiptController(receiptService)
+	duplicateCtrl := controllers.NewDuplicateController(duplicateService, transService, auditService)
+	templateCtrl := controllers.NewTemplateController(templateService, auditService)
+	billCtrl := controllers.NewBillController(billService)
+	subscriptionCtrl := controllers.NewSubscriptionController(subscriptionService)
+	calendarCtrl := controllers.NewCalendarController(calendarService)
+	accountCtrl := controllers.NewAccountController(accountService, auditService)
+	reconCtrl := controllers.NewReconciliationController(reconService)
 
 	// Setup Gin
 	gin.SetMode(gin.ReleaseMode)
 	app = gin.New()
 	app.Use(gin.Recovery())
 
+	// Only proxies listed in TRUSTED_PROXIES (comma-separated IPs or CIDRs) may set
+	// X-Forwarded-For; otherwise the client IP is the connecting address.
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := app.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Middleware
 	frontendURL := os.Getenv("FRONTEND_URL")
 	allowedOrigins := []string{"http://localhost:5173", "http://localhost:3000"}
@@ -48,14 +123,14 @@ func init() {
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 	}))
 
 	// Setup Routes
-	routes.SetupRoutes(app, authCtrl, catCtrl, transCtrl)
+	routes.SetupRoutes(app, authCtrl, catCtrl, transCtrl, budgetCtrl, alertCtrl, pushCtrl, syncCtrl, trashCtrl, auditCtrl, ruleCtrl, payeeCtrl, telegramCtrl, receiptCtrl, accountCtrl, reconCtrl, duplicateCtrl, templateCtrl, billCtrl, subscriptionCtrl, calendarCtrl)
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
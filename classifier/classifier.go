@@ -0,0 +1,160 @@
+// Package classifier implements a small multinomial naive Bayes text classifier
+// that can learn and unlearn examples one at a time.
+package classifier
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Prediction is a label with its posterior probability among all known labels.
+type Prediction struct {
+	Label       uint
+	Probability float64
+}
+
+// NaiveBayes counts features per label and scores with Laplace smoothing.
+// It is not safe for concurrent use.
+type NaiveBayes struct {
+	docs    map[uint]int
+	counts  map[uint]map[string]int
+	totals  map[uint]int
+	vocab   map[string]int
+	allDocs int
+}
+
+func New() *NaiveBayes {
+	return &NaiveBayes{
+		docs:   make(map[uint]int),
+		counts: make(map[uint]map[string]int),
+		totals: make(map[uint]int),
+		vocab:  make(map[string]int),
+	}
+}
+
+// Learn adds one example for label.
+func (nb *NaiveBayes) Learn(label uint, features []string) {
+	if len(features) == 0 {
+		return
+	}
+	if nb.counts[label] == nil {
+		nb.counts[label] = make(map[string]int)
+	}
+	nb.docs[label]++
+	nb.allDocs++
+	for _, f := range features {
+		nb.counts[label][f]++
+		nb.totals[label]++
+		nb.vocab[f]++
+	}
+}
+
+// Unlearn removes an example previously passed to Learn.
+func (nb *NaiveBayes) Unlearn(label uint, features []string) {
+	if len(features) == 0 || nb.docs[label] == 0 {
+		return
+	}
+	nb.docs[label]--
+	nb.allDocs--
+	for _, f := range features {
+		if nb.counts[label][f] == 0 {
+			continue
+		}
+		nb.counts[label][f]--
+		nb.totals[label]--
+		if nb.counts[label][f] == 0 {
+			delete(nb.counts[label], f)
+		}
+		if nb.vocab[f]--; nb.vocab[f] <= 0 {
+			delete(nb.vocab, f)
+		}
+	}
+	if nb.docs[label] == 0 {
+		delete(nb.docs, label)
+		delete(nb.counts, label)
+		delete(nb.totals, label)
+	}
+}
+
+// Predict ranks labels for the features, most likely first. Features never seen in
+// training are ignored; if none are known there is nothing to go on and it returns nil.
+func (nb *NaiveBayes) Predict(features []string) []Prediction {
+	known := make([]string, 0, len(features))
+	for _, f := range features {
+		if nb.vocab[f] > 0 {
+			known = append(known, f)
+		}
+	}
+	if len(known) == 0 || nb.allDocs == 0 {
+		return nil
+	}
+
+	vocabSize := float64(len(nb.vocab))
+	scores := make(map[uint]float64, len(nb.docs))
+	best := math.Inf(-1)
+	for label, docs := range nb.docs {
+		score := math.Log(float64(docs) / float64(nb.allDocs))
+		denominator := float64(nb.totals[label]) + vocabSize
+		for _, f := range known {
+			score += math.Log((float64(nb.counts[label][f]) + 1) / denominator)
+		}
+		scores[label] = score
+		if score > best {
+			best = score
+		}
+	}
+
+	// Normalize log scores into probabilities without underflowing
+	var sum float64
+	predictions := make([]Prediction, 0, len(scores))
+	for label, score := range scores {
+		p := math.Exp(score - best)
+		sum += p
+		predictions = append(predictions, Prediction{Label: label, Probability: p})
+	}
+	for i := range predictions {
+		predictions[i].Probability /= sum
+	}
+	sort.Slice(predictions, func(i, j int) bool {
+		if predictions[i].Probability == predictions[j].Probability {
+			return predictions[i].Label < predictions[j].Label
+		}
+		return predictions[i].Probability > predictions[j].Probability
+	})
+	return predictions
+}
+
+// Features turns a transaction description and amount into classifier features:
+// lowercased word tokens (numbers and single letters dropped) plus an amount bucket
+// on a half-decade log scale, so 20,000 and 45,000 share a bucket but 20,000 and
+// 200,000 do not.
+func Features(description string, amount float64) []string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	features := make([]string, 0, len(words)+1)
+	for _, w := range words {
+		if len([]rune(w)) < 2 || isNumber(w) {
+			continue
+		}
+		features = append(features, w)
+	}
+	if amount > 0 {
+		bucket := int(math.Floor(math.Log10(amount) * 2))
+		features = append(features, "amount:"+strconv.Itoa(bucket))
+	}
+	return features
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package classifier
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	got := Features("GOFOOD*MCD Kemang 123", 56000)
+	want := []string{"gofood", "mcd", "kemang", "amount:9"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestPredictRanksByHistory(t *testing.T) {
+	nb := New()
+	nb.Learn(1, Features("GOFOOD MCD KEMANG", 56000))
+	nb.Learn(1, Features("GOFOOD KFC", 48000))
+	nb.Learn(2, Features("PLN TOKEN LISTRIK", 200000))
+	nb.Learn(3, Features("GOJEK RIDE", 25000))
+
+	predictions := nb.Predict(Features("gofood hokben", 61000))
+	if len(predictions) != 3 {
+		t.Fatalf("Expected 3 predictions, got %d", len(predictions))
+	}
+	if predictions[0].Label != 1 {
+		t.Errorf("Expected label 1 first, got %+v", predictions)
+	}
+
+	var sum float64
+	for _, p := range predictions {
+		sum += p.Probability
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("Expected probabilities to sum to 1, got %v", sum)
+	}
+
+	if got := nb.Predict([]string{"unseen"}); got != nil {
+		t.Errorf("Expected nil for unknown features, got %v", got)
+	}
+}
+
+func TestUnlearnReversesLearn(t *testing.T) {
+	nb := New()
+	nb.Learn(1, Features("INDOMARET", 30000))
+	nb.Learn(2, Features("INDOMARET", 30000))
+	nb.Unlearn(2, Features("INDOMARET", 30000))
+
+	predictions := nb.Predict(Features("INDOMARET", 30000))
+	if len(predictions) != 1 || predictions[0].Label != 1 || predictions[0].Probability != 1 {
+		t.Errorf("Expected only label 1 with certainty, got %+v", predictions)
+	}
+}
@@ -39,35 +39,183 @@ func InitDB() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	dedupeBudgets(db)
+	dedupeUUIDs(db)
+	hashCalendarTokens(db)
+	dropPushEndpointIndex(db)
+
 	// Auto Migration
-	err = db.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{})
+	err = db.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{}, &models.Budget{},
+		&models.AlertRule{}, &models.Notification{}, &models.PushSubscription{}, &models.VapidKey{},
+		&models.AuditLog{}, &models.HiddenCategory{}, &models.Rule{}, &models.Payee{},
+		&models.TelegramLink{}, &models.TelegramLinkCode{}, &models.EmailReceipt{},
+		&models.Account{}, &models.Reconciliation{}, &models.DuplicateDismissal{},
+		&models.TransactionTemplate{}, &models.Bill{}, &models.CalendarFeed{},
+		&models.SyncTombstone{}, &models.ReceiptAddress{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	DB = db
+	backfillUUIDs(db)
 	seedCategories(db)
+	migrateReceiptStatus(db)
 	log.Println("Database connected, migrated, and seeded successfully")
 }
 
+// migrateReceiptStatus moves receipts from before drafts, when each waited in
+// email_receipts for a confirm or reject, into the inbox: pending ones become
+// draft transactions, and the status column is dropped.
+func migrateReceiptStatus(db *gorm.DB) {
+	if !db.Migrator().HasColumn(&models.EmailReceipt{}, "status") {
+		return
+	}
+	var fallback models.Category
+	if err := db.Where("user_id IS NULL AND name = ?", "Uncategorized").First(&fallback).Error; err != nil {
+		log.Printf("Warning: Failed to migrate pending receipts: %v", err)
+		return
+	}
+	var pending []models.EmailReceipt
+	err := db.Where("status = ? AND transaction_id IS NULL", "pending").Find(&pending).Error
+	if err != nil {
+		log.Printf("Warning: Failed to migrate pending receipts: %v", err)
+		return
+	}
+
+	for _, receipt := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			draft := &models.Transaction{
+				UserID:      receipt.UserID,
+				Type:        "expense",
+				CategoryID:  fallback.ID,
+				Amount:      receipt.Amount,
+				Description: receipt.Merchant,
+				Payee:       receipt.Merchant,
+				Date:        receipt.Date,
+				Status:      "draft",
+			}
+			if err := tx.Create(draft).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.EmailReceipt{}).Where("id = ?", receipt.ID).Update("transaction_id", draft.ID).Error
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to migrate receipt %d: %v", receipt.ID, err)
+			return
+		}
+	}
+	if err := db.Migrator().DropColumn(&models.EmailReceipt{}, "status"); err != nil {
+		log.Printf("Warning: Failed to drop email_receipts.status: %v", err)
+	}
+}
+
 func seedCategories(db *gorm.DB) {
-	defaultCategories := []string{
-		"Salary", "Freelance", "Investment", "Gift", "Bonus", // Income-friendly
-		"Food & Beverage", "Transportation", "Shopping", "Rent", // Expense-friendly
-		"Utilities", "Entertainment", "Health", "Education",
+	defaultCategories := []struct {
+		Name string
+		Kind string
+	}{
+		{"Salary", "income"}, {"Freelance", "income"}, {"Bonus", "income"},
+		{"Investment", "both"}, {"Gift", "both"}, // Money goes both ways
+		{"Food & Beverage", "expense"}, {"Transportation", "expense"}, {"Shopping", "expense"},
+		{"Rent", "expense"}, {"Utilities", "expense"}, {"Entertainment", "expense"},
+		{"Health", "expense"}, {"Education", "expense"},
+		{"Uncategorized", "both"}, // Fallback when a category is deleted
 	}
 
-	for _, name := range defaultCategories {
+	for _, def := range defaultCategories {
 		var count int64
-		db.Model(&models.Category{}).Where("name = ? AND user_id IS NULL", name).Count(&count)
+		db.Model(&models.Category{}).Where("name = ? AND user_id IS NULL", def.Name).Count(&count)
 		if count == 0 {
 			cat := models.Category{
-				Name:   name,
+				Name:   def.Name,
+				Kind:   def.Kind,
 				UserID: nil,
 			}
 			if err := db.Create(&cat).Error; err != nil {
-				log.Printf("Warning: Failed to seed category %s: %v", name, err)
+				log.Printf("Warning: Failed to seed category %s: %v", def.Name, err)
 			}
+			continue
+		}
+
+		// Defaults seeded before kinds existed start out as "both"
+		err := db.Model(&models.Category{}).
+			Where("name = ? AND user_id IS NULL AND kind <> ?", def.Name, def.Kind).
+			Updates(map[string]interface{}{"kind": def.Kind, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			log.Printf("Warning: Failed to set kind for category %s: %v", def.Name, err)
+		}
+	}
+}
+
+// dedupeBudgets keeps only the newest budget per category and month, so the
+// unique index on them can be created over data written before it existed.
+func dedupeBudgets(db *gorm.DB) {
+	if !db.Migrator().HasTable("budgets") {
+		return
+	}
+	err := db.Exec(`DELETE FROM budgets b USING budgets newer
+		WHERE b.user_id = newer.user_id AND b.category_id = newer.category_id
+		AND b.year = newer.year AND b.month = newer.month AND b.id < newer.id`).Error
+	if err != nil {
+		log.Printf("Warning: Failed to remove duplicate budgets: %v", err)
+	}
+}
+
+// hashCalendarTokens replaces the plaintext calendar feed tokens stored before
+// only their hashes were kept, so existing subscriptions keep working.
+func hashCalendarTokens(db *gorm.DB) {
+	if !db.Migrator().HasColumn("calendar_feeds", "token") {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE calendar_feeds ADD COLUMN IF NOT EXISTS token_hash varchar(64)").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE calendar_feeds SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex')").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE calendar_feeds DROP COLUMN token").Error
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to hash calendar feed tokens: %v", err)
+	}
+}
+
+// dropPushEndpointIndex drops the unique index on push endpoints alone, from
+// when a device's subscription moved to whichever user registered it last.
+// Subscriptions are now unique per user and endpoint.
+func dropPushEndpointIndex(db *gorm.DB) {
+	if !db.Migrator().HasIndex(&models.PushSubscription{}, "idx_push_subscriptions_endpoint") {
+		return
+	}
+	if err := db.Migrator().DropIndex(&models.PushSubscription{}, "idx_push_subscriptions_endpoint"); err != nil {
+		log.Printf("Warning: Failed to drop the push endpoint index: %v", err)
+	}
+}
+
+// dedupeUUIDs gives fresh uuids to rows whose uuid is empty or repeats an older
+// row's for the same user, so the unique index on them can be created over data
+// written before it existed.
+func dedupeUUIDs(db *gorm.DB) {
+	for _, table := range []string{"categories", "transactions"} {
+		if !db.Migrator().HasColumn(table, "uuid") {
+			continue
+		}
+		err := db.Exec(`UPDATE ` + table + ` t SET uuid = gen_random_uuid()
+			WHERE t.uuid = '' OR EXISTS (SELECT 1 FROM ` + table + ` older
+			WHERE older.user_id IS NOT DISTINCT FROM t.user_id AND older.uuid = t.uuid AND older.id < t.id)`).Error
+		if err != nil {
+			log.Printf("Warning: Failed to remove duplicate uuids from %s: %v", table, err)
+		}
+	}
+}
+
+// backfillUUIDs assigns sync identifiers to rows created before the uuid column existed.
+func backfillUUIDs(db *gorm.DB) {
+	for _, table := range []string{"categories", "transactions"} {
+		err := db.Exec("UPDATE " + table + " SET uuid = gen_random_uuid() WHERE uuid IS NULL OR uuid = ''").Error
+		if err != nil {
+			log.Printf("Warning: Failed to backfill uuids for %s: %v", table, err)
 		}
 	}
 }
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	service *services.AccountService
+	audit   *services.AuditService
+}
+
+func NewAccountController(service *services.AccountService, audit *services.AuditService) *AccountController {
+	return &AccountController{service, audit}
+}
+
+type accountInput struct {
+	Name           string  `json:"name" binding:"required"`
+	Type           string  `json:"type"`
+	OpeningBalance float64 `json:"opening_balance"`
+}
+
+func (in accountInput) toModel(userID uint) *models.Account {
+	return &models.Account{
+		UserID:         userID,
+		Name:           in.Name,
+		Type:           in.Type,
+		OpeningBalance: in.OpeningBalance,
+	}
+}
+
+func (ctrl *AccountController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	accounts, err := ctrl.service.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (ctrl *AccountController) Create(c *gin.Context) {
+	var input accountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	account := input.toModel(userID)
+	if err := ctrl.service.Create(account); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditCreate, "account", account.ID, nil, account)
+
+	c.JSON(http.StatusCreated, account)
+}
+
+func (ctrl *AccountController) Update(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input accountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before, err := ctrl.service.GetByID(uint(id), userID)
+	if err != nil {
+		respondAccountError(c, err)
+		return
+	}
+	account := input.toModel(userID)
+	account.ID = uint(id)
+	if err := ctrl.service.Update(account); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "account", account.ID, before, account)
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (ctrl *AccountController) Delete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	before, err := ctrl.service.GetByID(uint(id), userID)
+	if err != nil {
+		respondAccountError(c, err)
+		return
+	}
+	if err := ctrl.service.Delete(uint(id), userID); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditDelete, "account", uint(id), before, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
+
+func respondAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	case errors.Is(err, services.ErrAccountInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AlertController struct {
+	service *services.AlertService
+}
+
+func NewAlertController(service *services.AlertService) *AlertController {
+	return &AlertController{service}
+}
+
+type alertRuleInput struct {
+	Type       string  `json:"type" binding:"required"`
+	CategoryID *uint   `json:"category_id"`
+	Threshold  float64 `json:"threshold"`
+	Channels   string  `json:"channels"`
+	WebhookURL string  `json:"webhook_url"`
+	Enabled    *bool   `json:"enabled"`
+}
+
+func (in alertRuleInput) toModel(userID uint) *models.AlertRule {
+	enabled := true
+	if in.Enabled != nil {
+		enabled = *in.Enabled
+	}
+	return &models.AlertRule{
+		UserID:     userID,
+		Type:       in.Type,
+		CategoryID: in.CategoryID,
+		Threshold:  in.Threshold,
+		Channels:   in.Channels,
+		WebhookURL: in.WebhookURL,
+		Enabled:    enabled,
+	}
+}
+
+func (ctrl *AlertController) GetRules(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	rules, err := ctrl.service.GetRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (ctrl *AlertController) CreateRule(c *gin.Context) {
+	var input alertRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	rule := input.toModel(userID)
+	if err := ctrl.service.CreateRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (ctrl *AlertController) UpdateRule(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input alertRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := input.toModel(userID)
+	rule.ID = uint(id)
+	if err := ctrl.service.UpdateRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (ctrl *AlertController) DeleteRule(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.DeleteRule(uint(id), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted"})
+}
+
+func (ctrl *AlertController) GetNotifications(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, unread, err := ctrl.service.GetNotifications(userID, unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread_count":  unread,
+	})
+}
+
+func (ctrl *AlertController) MarkRead(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.MarkRead(uint(id), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+func (ctrl *AlertController) MarkUnread(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.MarkUnread(uint(id), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as unread"})
+}
+
+func (ctrl *AlertController) MarkAllRead(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.MarkAllRead(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	service *services.AuditService
+}
+
+func NewAuditController(service *services.AuditService) *AuditController {
+	return &AuditController{service}
+}
+
+// actorFrom identifies the authenticated user and client address for audit entries.
+func actorFrom(c *gin.Context) services.Actor {
+	return services.Actor{
+		UserID: c.MustGet("user_id").(uint),
+		IP:     c.ClientIP(),
+	}
+}
+
+func (ctrl *AuditController) GetTransactionHistory(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	entries, err := ctrl.service.GetHistory(userID, "transaction", uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (ctrl *AuditController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	filter := make(map[string]interface{})
+	filter["entity_type"] = c.Query("entity_type")
+	filter["action"] = c.Query("action")
+	if idStr := c.Query("entity_id"); idStr != "" {
+		id, _ := strconv.Atoi(idStr)
+		filter["entity_id"] = uint(id)
+	}
+	if from, err := time.Parse("2006-01-02", c.Query("start_date")); err == nil {
+		filter["start_date"] = from
+	}
+	if to, err := time.Parse("2006-01-02", c.Query("end_date")); err == nil {
+		filter["end_date"] = to.AddDate(0, 0, 1)
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filter["limit"] = limit
+	}
+
+	entries, err := ctrl.service.GetAll(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
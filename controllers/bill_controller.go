@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type BillController struct {
+	service *services.BillService
+}
+
+func NewBillController(service *services.BillService) *BillController {
+	return &BillController{service}
+}
+
+// billInput is the body for creating and updating bills. start_date (YYYY-MM-DD)
+// only picks the first period's month and defaults to the current one. It must
+// fall within billStartYears of today, which keeps period counts small.
+const billStartYears = 20
+
+type billInput struct {
+	Name             string  `json:"name" binding:"required"`
+	Amount           float64 `json:"amount"`
+	DueDay           int     `json:"due_day" binding:"required"`
+	IntervalMonths   int     `json:"interval_months"`
+	StartDate        string  `json:"start_date"`
+	Payee            string  `json:"payee"`
+	CategoryID       *uint   `json:"category_id"`
+	AccountID        *uint   `json:"account_id"`
+	RemindDaysBefore *int    `json:"remind_days_before"`
+	Channels         string  `json:"channels"`
+	Active           *bool   `json:"active"`
+}
+
+func (in billInput) toModel(userID uint) (*models.Bill, error) {
+	bill := &models.Bill{
+		UserID:           userID,
+		Name:             in.Name,
+		Amount:           in.Amount,
+		DueDay:           in.DueDay,
+		IntervalMonths:   in.IntervalMonths,
+		Payee:            in.Payee,
+		CategoryID:       in.CategoryID,
+		AccountID:        in.AccountID,
+		RemindDaysBefore: 3,
+		Channels:         in.Channels,
+		Active:           true,
+	}
+	if in.RemindDaysBefore != nil {
+		bill.RemindDaysBefore = *in.RemindDaysBefore
+	}
+	if in.Active != nil {
+		bill.Active = *in.Active
+	}
+	if in.StartDate != "" {
+		loc, _ := time.LoadLocation("Asia/Jakarta")
+		start, err := time.ParseInLocation("2006-01-02", in.StartDate, loc)
+		if err != nil {
+			return nil, errors.New("start_date must be YYYY-MM-DD")
+		}
+		now := time.Now()
+		if start.Before(now.AddDate(-billStartYears, 0, 0)) || start.After(now.AddDate(billStartYears, 0, 0)) {
+			return nil, fmt.Errorf("start_date must be within %d years of today", billStartYears)
+		}
+		bill.StartDate = start
+	}
+	return bill, nil
+}
+
+// GetAll lists the user's bills with the status of their current period.
+func (ctrl *BillController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	bills, err := ctrl.service.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bills)
+}
+
+// GetUpcoming lists bills due in the next days (default 30) and those overdue,
+// for the dashboard.
+func (ctrl *BillController) GetUpcoming(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	days, _ := strconv.Atoi(c.Query("days"))
+	if days <= 0 || days > 366 {
+		days = 30
+	}
+
+	periods, total, err := ctrl.service.Upcoming(userID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upcoming bills"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bills": periods, "total_due": total})
+}
+
+// GetPeriods returns the bill's recent periods (count, default 6) and the next one.
+func (ctrl *BillController) GetPeriods(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+	count, _ := strconv.Atoi(c.Query("count"))
+	if count <= 0 || count > 36 {
+		count = 6
+	}
+
+	periods, err := ctrl.service.GetPeriods(uint(id), userID, count)
+	if err != nil {
+		respondBillError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, periods)
+}
+
+func (ctrl *BillController) Create(c *gin.Context) {
+	var input billInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	bill, err := input.toModel(userID)
+	if err == nil {
+		err = ctrl.service.Create(bill)
+	}
+	if err != nil {
+		respondBillError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bill)
+}
+
+func (ctrl *BillController) Update(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input billInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bill, err := input.toModel(userID)
+	if err == nil {
+		bill.ID = uint(id)
+		err = ctrl.service.Update(bill)
+	}
+	if err != nil {
+		respondBillError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
+
+func (ctrl *BillController) Delete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.Delete(uint(id), userID); err != nil {
+		respondBillError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bill deleted"})
+}
+
+func respondBillError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBillNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+	case errors.Is(err, services.ErrAccountNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+	case errors.Is(err, services.ErrCategoryKindMatch),
+		errors.Is(err, services.ErrChannelUnavailable),
+		errors.Is(err, services.ErrWebhookRequired),
+		errors.Is(err, services.ErrBillName),
+		errors.Is(err, services.ErrBillDueDay),
+		errors.Is(err, services.ErrBillInterval),
+		errors.Is(err, services.ErrBillAmount),
+		errors.Is(err, services.ErrBillRemind),
+		errors.Is(err, services.ErrBillMatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Bill request failed"})
+	}
+}
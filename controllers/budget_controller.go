@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Budget years run from minBudgetYear to budgetYearsAhead past the current one.
+// Envelopes are computed month by month, so an unbounded year is costly.
+const (
+	minBudgetYear    = 1970
+	budgetYearsAhead = 10
+)
+
+func budgetYearValid(year int) bool {
+	return year >= minBudgetYear && year <= time.Now().Year()+budgetYearsAhead
+}
+
+type BudgetController struct {
+	service *services.BudgetService
+	audit   *services.AuditService
+}
+
+func NewBudgetController(service *services.BudgetService, audit *services.AuditService) *BudgetController {
+	return &BudgetController{service, audit}
+}
+
+func (ctrl *BudgetController) GetMonth(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	month, year := periodFromQuery(c)
+	level, _ := strconv.Atoi(c.Query("level"))
+
+	data, err := ctrl.service.GetMonth(userID, month, year, level)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func (ctrl *BudgetController) Assign(c *gin.Context) {
+	var input struct {
+		CategoryID uint    `json:"category_id" binding:"required"`
+		Month      int     `json:"month" binding:"required,min=1,max=12"`
+		Year       int     `json:"year" binding:"required"`
+		Amount     float64 `json:"amount"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !budgetYearValid(input.Year) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("year must be between %d and %d", minBudgetYear, time.Now().Year()+budgetYearsAhead)})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	before := ctrl.service.GetAssignment(userID, input.CategoryID, input.Month, input.Year)
+	budget, err := ctrl.service.Assign(userID, input.CategoryID, input.Month, input.Year, input.Amount)
+	if errors.Is(err, services.ErrCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save budget"})
+		return
+	}
+	action := services.AuditUpdate
+	if before == nil {
+		action = services.AuditCreate
+	}
+	ctrl.audit.Record(actorFrom(c), userID, action, "budget", budget.ID, before, budget)
+
+	c.JSON(http.StatusOK, budget)
+}
+
+func (ctrl *BudgetController) SetMode(c *gin.Context) {
+	var input struct {
+		Mode string `json:"mode" binding:"required,oneof=simple envelope"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if err := ctrl.service.SetMode(userID, input.Mode); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget mode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget mode updated", "mode": input.Mode})
+}
+
+func (ctrl *BudgetController) Delete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	before, err := ctrl.service.GetByID(uint(id), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+	if err := ctrl.service.Delete(uint(id), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditDelete, "budget", before.ID, before, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted"})
+}
+
+// periodFromQuery reads month and year query params, defaulting to the current
+// month in Jakarta when either is missing or out of range.
+func periodFromQuery(c *gin.Context) (int, int) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now().In(loc)
+
+	month, _ := strconv.Atoi(c.Query("month"))
+	year, _ := strconv.Atoi(c.Query("year"))
+	if month < 1 || month > 12 || !budgetYearValid(year) {
+		return int(now.Month()), now.Year()
+	}
+	return month, year
+}
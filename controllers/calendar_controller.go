@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/antigravity/finance-tracker/ical"
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	service *services.CalendarService
+}
+
+func NewCalendarController(service *services.CalendarService) *CalendarController {
+	return &CalendarController{service}
+}
+
+// feedResponse gives a newly issued feed's URL both as https and as webcal,
+// which most calendar apps open as a subscription.
+func feedResponse(c *gin.Context, feed *models.CalendarFeed) gin.H {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	path := c.Request.Host + "/api/ical/" + feed.Token + ".ics"
+	return gin.H{
+		"url":        scheme + "://" + path,
+		"webcal_url": "webcal://" + path,
+		"created_at": feed.CreatedAt,
+	}
+}
+
+// Get tells whether the user has a feed, or 404s before one is created. Only a
+// hash of the token is stored, so the URL itself is shown just once by Rotate.
+func (ctrl *CalendarController) Get(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	feed, err := ctrl.service.GetFeed(userID)
+	if err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"created_at": feed.CreatedAt,
+		"message":    "The feed URL is only shown when created; rotate it to get a new one",
+	})
+}
+
+// Rotate creates the user's feed, or replaces its token so the old URL stops working.
+func (ctrl *CalendarController) Rotate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	feed, err := ctrl.service.Rotate(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, feedResponse(c, feed))
+}
+
+func (ctrl *CalendarController) Disable(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	if err := ctrl.service.Disable(userID); err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed disabled"})
+}
+
+// Feed serves the calendar to apps subscribed to it. The token in the path is
+// the only credential; a trailing .ics is allowed for apps that want one.
+func (ctrl *CalendarController) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	body, err := ctrl.service.Render(token)
+	if err != nil {
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, ical.ContentType, body)
+}
+
+func respondCalendarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCalendarFeedNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feed"})
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,15 +11,20 @@ import (
 
 type CategoryController struct {
 	service *services.CategoryService
+	audit   *services.AuditService
 }
 
-func NewCategoryController(service *services.CategoryService) *CategoryController {
-	return &CategoryController{service}
+func NewCategoryController(service *services.CategoryService, audit *services.AuditService) *CategoryController {
+	return &CategoryController{service, audit}
 }
 
 func (ctrl *CategoryController) Create(c *gin.Context) {
 	var input struct {
-		Name string `json:"name" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Icon     string `json:"icon" binding:"max=50"`
+		Color    string `json:"color"`
+		ParentID uint   `json:"parent_id"`
+		Kind     string `json:"kind" binding:"omitempty,oneof=income expense both"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -27,17 +33,24 @@ func (ctrl *CategoryController) Create(c *gin.Context) {
 	}
 
 	userID := c.MustGet("user_id").(uint)
-	if err := ctrl.service.Create(userID, input.Name); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+	category, err := ctrl.service.Create(userID, input.Name, input.Icon, input.Color, input.ParentID, input.Kind)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to create category")
 		return
 	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditCreate, "category", category.ID, nil, category)
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Category created"})
+	c.JSON(http.StatusCreated, gin.H{"message": "Category created", "category": category})
 }
 
+// GetAll lists categories. Pass include_archived=true or include_hidden=true to
+// also get archived categories or defaults the user has hidden, and kind=income or
+// kind=expense to get only categories usable for that transaction type.
 func (ctrl *CategoryController) GetAll(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
-	categories, err := ctrl.service.GetAll(userID)
+	includeArchived := c.Query("include_archived") == "true"
+	includeHidden := c.Query("include_hidden") == "true"
+	categories, err := ctrl.service.GetAll(userID, includeArchived, includeHidden, c.Query("kind"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
 		return
@@ -46,12 +59,184 @@ func (ctrl *CategoryController) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, categories)
 }
 
+// GetTree lists categories nested under their parents. It takes the same query
+// params as GetAll.
+func (ctrl *CategoryController) GetTree(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	includeArchived := c.Query("include_archived") == "true"
+	includeHidden := c.Query("include_hidden") == "true"
+	tree, err := ctrl.service.GetTree(userID, includeArchived, includeHidden, c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// Delete removes a category. If it is still in use the client must send either
+// replacement_category_id or uncategorized=true, as query params or JSON body.
 func (ctrl *CategoryController) Delete(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := ctrl.service.Delete(uint(id)); err != nil {
+	userID := c.MustGet("user_id").(uint)
+
+	var input struct {
+		ReplacementCategoryID uint `json:"replacement_category_id" form:"replacement_category_id"`
+		Uncategorized         bool `json:"uncategorized" form:"uncategorized"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	before, _ := ctrl.service.GetByID(uint(id), userID)
+	moved, err := ctrl.service.Delete(actorFrom(c), uint(id), input.ReplacementCategoryID, input.Uncategorized)
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	case errors.Is(err, services.ErrDefaultCategory):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrReplacementRequired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrInvalidReplacement):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
 		return
 	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditDelete, "category", uint(id), before, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted", "reassigned_transactions": moved})
+}
+
+func (ctrl *CategoryController) Update(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+	var input struct {
+		Name     *string `json:"name"`
+		Icon     *string `json:"icon" binding:"omitempty,max=50"`
+		Color    *string `json:"color"`
+		ParentID *uint   `json:"parent_id"` // 0 moves the category to the top level
+		Kind     *string `json:"kind" binding:"omitempty,oneof=income expense both"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before, _ := ctrl.service.GetByID(uint(id), userID)
+	category, err := ctrl.service.Update(uint(id), userID, input.Name, input.Icon, input.Color, input.ParentID, input.Kind)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to update category")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "category", category.ID, before, category)
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Merge folds the category into target_category_id: its transactions, budgets and
+// alert rules move over and the category itself is deleted.
+func (ctrl *CategoryController) Merge(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input struct {
+		TargetCategoryID uint `json:"target_category_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before, _ := ctrl.service.GetByID(uint(id), userID)
+	moved, err := ctrl.service.Merge(actorFrom(c), uint(id), input.TargetCategoryID)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to merge categories")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditDelete, "category", uint(id), before, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                 "Categories merged",
+		"target_category_id":      input.TargetCategoryID,
+		"reassigned_transactions": moved,
+	})
+}
+
+func (ctrl *CategoryController) Archive(c *gin.Context) {
+	ctrl.setArchived(c, true)
+}
+
+func (ctrl *CategoryController) Unarchive(c *gin.Context) {
+	ctrl.setArchived(c, false)
+}
+
+func (ctrl *CategoryController) setArchived(c *gin.Context, archived bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	before, _ := ctrl.service.GetByID(uint(id), userID)
+	category, err := ctrl.service.SetArchived(uint(id), userID, archived)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to update category")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "category", category.ID, before, category)
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Hide removes a default category from this user's pickers. Other users are unaffected.
+func (ctrl *CategoryController) Hide(c *gin.Context) {
+	ctrl.setHidden(c, true)
+}
+
+func (ctrl *CategoryController) Unhide(c *gin.Context) {
+	ctrl.setHidden(c, false)
+}
+
+func (ctrl *CategoryController) setHidden(c *gin.Context, hidden bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.SetHidden(uint(id), userID, hidden); err != nil {
+		respondCategoryError(c, err, "Failed to update category")
+		return
+	}
+
+	message := "Category shown"
+	if hidden {
+		message = "Category hidden"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "hidden": hidden})
+}
+
+func respondCategoryError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+	case errors.Is(err, services.ErrDefaultCategory), errors.Is(err, services.ErrOwnCategoryHide):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCategoryNameTaken), errors.Is(err, services.ErrCategoryKindInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidReplacement), errors.Is(err, services.ErrInvalidColor),
+		errors.Is(err, services.ErrInvalidParent), errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrCategoryTooDeep), errors.Is(err, services.ErrInvalidKind),
+		errors.Is(err, services.ErrCategoryNameEmpty), errors.Is(err, services.ErrReplacementKind):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
 }
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type DuplicateController struct {
+	service *services.DuplicateService
+	trans   *services.TransactionService
+	audit   *services.AuditService
+}
+
+func NewDuplicateController(service *services.DuplicateService, trans *services.TransactionService, audit *services.AuditService) *DuplicateController {
+	return &DuplicateController{service, trans, audit}
+}
+
+// GetAll lists likely duplicate pairs among transactions from the last days
+// (default 90, at most 366).
+func (ctrl *DuplicateController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	days, _ := strconv.Atoi(c.Query("days"))
+	if days <= 0 || days > 366 {
+		days = 90
+	}
+
+	pairs, err := ctrl.service.Find(userID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find duplicates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pairs)
+}
+
+// Merge keeps keep_id, folding in what it lacks from remove_id, and deletes remove_id.
+func (ctrl *DuplicateController) Merge(c *gin.Context) {
+	var input struct {
+		KeepID   uint `json:"keep_id" binding:"required"`
+		RemoveID uint `json:"remove_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	keepBefore, err := ctrl.trans.GetByID(input.KeepID, userID)
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+	removed, err := ctrl.trans.GetByID(input.RemoveID, userID)
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+	kept, err := ctrl.trans.MergeDuplicate(input.KeepID, input.RemoveID, userID)
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+	if keepBefore.Version != kept.Version {
+		ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "transaction", kept.ID, keepBefore, kept)
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditDelete, "transaction", removed.ID, removed, nil)
+
+	c.JSON(http.StatusOK, kept)
+}
+
+// Dismiss marks the two transactions in ids as distinct.
+func (ctrl *DuplicateController) Dismiss(c *gin.Context) {
+	var input struct {
+		IDs []uint `json:"ids" binding:"required,len=2"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	for _, id := range input.IDs {
+		if _, err := ctrl.trans.GetByID(id, userID); err != nil {
+			respondDuplicateError(c, err)
+			return
+		}
+	}
+	if err := ctrl.service.Dismiss(userID, input.IDs[0], input.IDs[1]); err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Duplicate dismissed"})
+}
+
+func respondDuplicateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+	case errors.Is(err, services.ErrDuplicatePair):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransactionLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update duplicates"})
+	}
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PayeeController struct {
+	service *services.PayeeService
+}
+
+func NewPayeeController(service *services.PayeeService) *PayeeController {
+	return &PayeeController{service}
+}
+
+type payeeInput struct {
+	Name              string   `json:"name" binding:"required"`
+	Aliases           []string `json:"aliases"`
+	DefaultCategoryID *uint    `json:"default_category_id"`
+}
+
+func (in payeeInput) toModel(userID uint) *models.Payee {
+	return &models.Payee{
+		UserID:            userID,
+		Name:              in.Name,
+		Aliases:           in.Aliases,
+		DefaultCategoryID: in.DefaultCategoryID,
+	}
+}
+
+func (ctrl *PayeeController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	payees, err := ctrl.service.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payees)
+}
+
+func (ctrl *PayeeController) Create(c *gin.Context) {
+	var input payeeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	payee := input.toModel(userID)
+	if err := ctrl.service.Create(payee); err != nil {
+		respondPayeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payee)
+}
+
+func (ctrl *PayeeController) Update(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input payeeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee := input.toModel(userID)
+	payee.ID = uint(id)
+	if err := ctrl.service.Update(actorFrom(c), payee); err != nil {
+		respondPayeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payee)
+}
+
+func (ctrl *PayeeController) Delete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	if err := ctrl.service.Delete(actorFrom(c), uint(id)); err != nil {
+		respondPayeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payee deleted"})
+}
+
+// GetTop reports the payees with the largest totals between start_date and
+// end_date (inclusive, YYYY-MM-DD), defaulting to the current month. type is
+// expense unless set to income; limit defaults to 10.
+func (ctrl *PayeeController) GetTop(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now().In(loc)
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	endDate := startDate.AddDate(0, 1, 0)
+	if from, err := time.ParseInLocation("2006-01-02", c.Query("start_date"), loc); err == nil {
+		startDate = from
+	}
+	if to, err := time.ParseInLocation("2006-01-02", c.Query("end_date"), loc); err == nil {
+		endDate = to.AddDate(0, 0, 1)
+	}
+
+	txType := c.DefaultQuery("type", "expense")
+	if txType != "income" && txType != "expense" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be income or expense"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	payees, err := ctrl.service.GetTop(userID, txType, startDate, endDate, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top payees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": startDate,
+		"end_date":   endDate,
+		"type":       txType,
+		"payees":     payees,
+	})
+}
+
+func respondPayeeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPayeeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payee not found"})
+	case errors.Is(err, services.ErrPayeeAliasTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
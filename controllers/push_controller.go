@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PushController struct {
+	service *services.PushService
+}
+
+func NewPushController(service *services.PushService) *PushController {
+	return &PushController{service}
+}
+
+func (ctrl *PushController) GetPublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"public_key": ctrl.service.PublicKey()})
+}
+
+func (ctrl *PushController) Subscribe(c *gin.Context) {
+	// Mirrors the shape of PushSubscription.toJSON() in the browser
+	var input struct {
+		Endpoint string `json:"endpoint" binding:"required,url"`
+		Keys     struct {
+			P256dh string `json:"p256dh" binding:"required"`
+			Auth   string `json:"auth" binding:"required"`
+		} `json:"keys" binding:"required"`
+		Device string `json:"device"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device := input.Device
+	if device == "" {
+		device = c.Request.UserAgent()
+	}
+	if len(device) > 150 {
+		device = device[:150]
+	}
+
+	sub := &models.PushSubscription{
+		UserID:   c.MustGet("user_id").(uint),
+		Endpoint: input.Endpoint,
+		P256dh:   input.Keys.P256dh,
+		Auth:     input.Keys.Auth,
+		Device:   device,
+	}
+	if err := ctrl.service.Subscribe(sub); err != nil {
+		if errors.Is(err, services.ErrPushEndpoint) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (ctrl *PushController) GetSubscriptions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	subs, err := ctrl.service.GetSubscriptions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch push subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subs)
+}
+
+func (ctrl *PushController) Unsubscribe(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.Unsubscribe(uint(id), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription deleted"})
+}
+
+func (ctrl *PushController) SendTest(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	delivered, err := ctrl.service.SendToUser(userID, map[string]interface{}{
+		"title": "Finance Tracker",
+		"body":  "Push notifications are working.",
+		"url":   "/",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send push notification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"delivered": delivered})
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/antigravity/finance-tracker/receipts"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxInboundEmail caps the size of a raw email posted to the inbound webhook.
+const maxInboundEmail = 10 << 20
+
+type ReceiptController struct {
+	service *services.ReceiptService
+}
+
+func NewReceiptController(service *services.ReceiptService) *ReceiptController {
+	return &ReceiptController{service}
+}
+
+// Inbound accepts one raw MIME email from an inbound-mail provider, either as the
+// request body or in a "body-mime" (Mailgun) or "email" (SendGrid) form field.
+// The shared secret comes in X-Inbound-Secret or the secret query parameter.
+func (ctrl *ReceiptController) Inbound(c *gin.Context) {
+	secret := ctrl.service.InboundSecret()
+	if secret == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	given := c.GetHeader("X-Inbound-Secret")
+	if given == "" {
+		given = c.Query("secret")
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid inbound secret"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundEmail)
+	var raw []byte
+	contentType := c.ContentType()
+	if contentType == "multipart/form-data" || contentType == "application/x-www-form-urlencoded" {
+		field := c.PostForm("body-mime")
+		if field == "" {
+			field = c.PostForm("email")
+		}
+		raw = []byte(field)
+	} else {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Email is too large"})
+			return
+		}
+		raw = body
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is empty"})
+		return
+	}
+
+	receipt, err := ctrl.service.Ingest(raw)
+	switch {
+	case errors.Is(err, services.ErrReceiptUnreadable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReceiptNoUser), errors.Is(err, receipts.ErrNoTotal):
+		// Not retryable, so tell the provider it was handled
+		c.JSON(http.StatusOK, gin.H{"ignored": true, "reason": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ingest email"})
+	default:
+		c.JSON(http.StatusCreated, receipt)
+	}
+}
+
+// GetAddress returns the user's secret address for emailing receipts.
+func (ctrl *ReceiptController) GetAddress(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	address, err := ctrl.service.GetAddress(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch receipt address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// RotateAddress replaces the user's receipt address, e.g. once it has leaked.
+func (ctrl *ReceiptController) RotateAddress(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	address, err := ctrl.service.RotateAddress(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create receipt address"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+func (ctrl *ReceiptController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	list, err := ctrl.service.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch receipts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationController struct {
+	service *services.ReconciliationService
+}
+
+func NewReconciliationController(service *services.ReconciliationService) *ReconciliationController {
+	return &ReconciliationController{service}
+}
+
+// Start opens a session for the account in the path with the statement's last
+// day (YYYY-MM-DD) and closing balance.
+func (ctrl *ReconciliationController) Start(c *gin.Context) {
+	accountID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		StatementDate  string   `json:"statement_date" binding:"required"`
+		ClosingBalance *float64 `json:"closing_balance" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	statementDate, err := time.ParseInLocation("2006-01-02", input.StatementDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "statement_date must be YYYY-MM-DD"})
+		return
+	}
+
+	summary, err := ctrl.service.Start(actorFrom(c), uint(accountID), statementDate, *input.ClosingBalance)
+	if err != nil {
+		respondReconciliationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, summary)
+}
+
+func (ctrl *ReconciliationController) GetByAccount(c *gin.Context) {
+	accountID, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	recs, err := ctrl.service.GetByAccount(uint(accountID), userID)
+	if err != nil {
+		respondReconciliationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recs)
+}
+
+func (ctrl *ReconciliationController) GetByID(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	summary, err := ctrl.service.Get(uint(id), userID)
+	if err != nil {
+		respondReconciliationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (ctrl *ReconciliationController) Clear(c *gin.Context) {
+	ctrl.setCleared(c, true)
+}
+
+func (ctrl *ReconciliationController) Unclear(c *gin.Context) {
+	ctrl.setCleared(c, false)
+}
+
+func (ctrl *ReconciliationController) setCleared(c *gin.Context, cleared bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		IDs []uint `json:"ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := ctrl.service.SetCleared(actorFrom(c), uint(id), input.IDs, cleared)
+	if err != nil {
+		respondReconciliationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// Complete locks the cleared transactions. While the difference is not zero it
+// answers 409 with the summary so the client can show what is left.
+func (ctrl *ReconciliationController) Complete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	summary, locked, err := ctrl.service.Complete(actorFrom(c), uint(id))
+	if errors.Is(err, services.ErrReconciliationUnbalanced) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "reconciliation": summary})
+		return
+	}
+	if err != nil {
+		respondReconciliationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reconciliation": summary, "locked": locked})
+}
+
+func (ctrl *ReconciliationController) Cancel(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	if err := ctrl.service.Cancel(actorFrom(c), uint(id)); err != nil {
+		respondReconciliationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reconciliation cancelled"})
+}
+
+func respondReconciliationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	case errors.Is(err, services.ErrReconciliationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reconciliation not found"})
+	case errors.Is(err, services.ErrReconciliationExists), errors.Is(err, services.ErrReconciliationClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process reconciliation"})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type RuleController struct {
+	service *services.RuleService
+}
+
+func NewRuleController(service *services.RuleService) *RuleController {
+	return &RuleController{service}
+}
+
+type ruleInput struct {
+	Name       string   `json:"name" binding:"required"`
+	Priority   int      `json:"priority"`
+	MatchType  string   `json:"match_type"`
+	Pattern    string   `json:"pattern"`
+	MinAmount  *float64 `json:"min_amount"`
+	MaxAmount  *float64 `json:"max_amount"`
+	Type       string   `json:"type"`
+	AccountID  *uint    `json:"account_id"`
+	CategoryID *uint    `json:"category_id"`
+	Tags       []string `json:"tags"`
+	Payee      string   `json:"payee"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+func (in ruleInput) toModel(userID uint) *models.Rule {
+	enabled := true
+	if in.Enabled != nil {
+		enabled = *in.Enabled
+	}
+	return &models.Rule{
+		UserID:     userID,
+		Name:       in.Name,
+		Priority:   in.Priority,
+		MatchType:  in.MatchType,
+		Pattern:    in.Pattern,
+		MinAmount:  in.MinAmount,
+		MaxAmount:  in.MaxAmount,
+		Type:       in.Type,
+		AccountID:  in.AccountID,
+		CategoryID: in.CategoryID,
+		Tags:       in.Tags,
+		Payee:      in.Payee,
+		Enabled:    enabled,
+	}
+}
+
+func (ctrl *RuleController) GetRules(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	rules, err := ctrl.service.GetRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (ctrl *RuleController) CreateRule(c *gin.Context) {
+	var input ruleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	rule := input.toModel(userID)
+	if err := ctrl.service.CreateRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (ctrl *RuleController) UpdateRule(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input ruleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := input.toModel(userID)
+	rule.ID = uint(id)
+	if err := ctrl.service.UpdateRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (ctrl *RuleController) DeleteRule(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.DeleteRule(uint(id), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted"})
+}
+
+// TestRule dry-runs a rule (same body as create) against existing transactions
+// without saving anything.
+func (ctrl *RuleController) TestRule(c *gin.Context) {
+	var input ruleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	matches, total, err := ctrl.service.TestRule(input.toModel(userID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"match_count": total, "matches": matches})
+}
+
+// ApplyRules runs the enabled rules over every existing transaction. With
+// overwrite=true, rules also replace categories the user already picked.
+func (ctrl *RuleController) ApplyRules(c *gin.Context) {
+	var input struct {
+		Overwrite bool `json:"overwrite" form:"overwrite"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	matched, updated, err := ctrl.service.ApplyToExisting(actorFrom(c), userID, input.Overwrite)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"matched": matched, "updated": updated})
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionController struct {
+	service *services.SubscriptionService
+}
+
+func NewSubscriptionController(service *services.SubscriptionService) *SubscriptionController {
+	return &SubscriptionController{service}
+}
+
+// GetAll lists recurring charges detected in the user's history, costliest first,
+// with the monthly and annual totals.
+func (ctrl *SubscriptionController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	subscriptions, err := ctrl.service.Detect(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect subscriptions"})
+		return
+	}
+
+	monthly, annual := 0.0, 0.0
+	for _, s := range subscriptions {
+		monthly += s.MonthlyCost
+		annual += s.AnnualCost
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptions": subscriptions,
+		"monthly_cost":  math.Round(monthly*100) / 100,
+		"annual_cost":   math.Round(annual*100) / 100,
+	})
+}
+
+// Confirm turns a detected subscription, identified by its key, into a tracked bill.
+func (ctrl *SubscriptionController) Confirm(c *gin.Context) {
+	var input struct {
+		Key  string `json:"key" binding:"required"`
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	bill, err := ctrl.service.Confirm(userID, input.Key, input.Name)
+	switch {
+	case errors.Is(err, services.ErrSubscriptionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	case errors.Is(err, services.ErrSubscriptionTracked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bill)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SyncController struct {
+	service *services.SyncService
+}
+
+func NewSyncController(service *services.SyncService) *SyncController {
+	return &SyncController{service}
+}
+
+func (ctrl *SyncController) Sync(c *gin.Context) {
+	var input struct {
+		Cursor    string                  `json:"cursor"`
+		Mutations []services.SyncMutation `json:"mutations" binding:"max=500,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, m := range input.Mutations {
+		if m.Op != "upsert" && m.Op != "delete" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "op must be upsert or delete"})
+			return
+		}
+	}
+
+	resp, err := ctrl.service.Sync(actorFrom(c), input.Cursor, input.Mutations)
+	if errors.Is(err, services.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
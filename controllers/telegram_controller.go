@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/antigravity/finance-tracker/telegram"
+	"github.com/gin-gonic/gin"
+)
+
+type TelegramController struct {
+	service *services.TelegramService
+}
+
+// NewTelegramController accepts a nil service, in which case the bot endpoints
+// report that Telegram is not configured.
+func NewTelegramController(service *services.TelegramService) *TelegramController {
+	return &TelegramController{service}
+}
+
+func (ctrl *TelegramController) configured(c *gin.Context) bool {
+	if ctrl.service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Telegram bot is not configured"})
+		return false
+	}
+	return true
+}
+
+// WebhookEnabled reports whether the bot receives updates by webhook, so the
+// webhook route should exist at all.
+func (ctrl *TelegramController) WebhookEnabled() bool {
+	return ctrl.service != nil && ctrl.service.WebhookMode()
+}
+
+// Webhook receives updates pushed by Telegram. It answers 200 even when handling
+// fails, otherwise Telegram keeps redelivering the same update.
+func (ctrl *TelegramController) Webhook(c *gin.Context) {
+	if !ctrl.WebhookEnabled() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	secret := ctrl.service.WebhookSecret()
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(telegram.SecretHeader)), []byte(secret)) != 1 {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	var update telegram.Update
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	ctrl.service.HandleUpdate(c.Request.Context(), update)
+	c.Status(http.StatusOK)
+}
+
+// CreateLinkCode issues a one-time code to send to the bot as /start <code>.
+func (ctrl *TelegramController) CreateLinkCode(c *gin.Context) {
+	if !ctrl.configured(c) {
+		return
+	}
+	userID := c.MustGet("user_id").(uint)
+	code, err := ctrl.service.CreateLinkCode(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+func (ctrl *TelegramController) GetLink(c *gin.Context) {
+	if !ctrl.configured(c) {
+		return
+	}
+	userID := c.MustGet("user_id").(uint)
+	link, err := ctrl.service.GetLink(userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"linked": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"linked": true, "link": link})
+}
+
+func (ctrl *TelegramController) Unlink(c *gin.Context) {
+	if !ctrl.configured(c) {
+		return
+	}
+	userID := c.MustGet("user_id").(uint)
+	if err := ctrl.service.Unlink(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No Telegram chat is linked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Telegram chat unlinked"})
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TemplateController struct {
+	service *services.TemplateService
+	audit   *services.AuditService
+}
+
+func NewTemplateController(service *services.TemplateService, audit *services.AuditService) *TemplateController {
+	return &TemplateController{service, audit}
+}
+
+type templateInput struct {
+	Name        string   `json:"name" binding:"required"`
+	Type        string   `json:"type" binding:"required,oneof=income expense"`
+	CategoryID  uint     `json:"category_id" binding:"required"`
+	Amount      float64  `json:"amount" binding:"required"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	AccountID   *uint    `json:"account_id"`
+}
+
+func (in templateInput) toModel(userID uint) *models.TransactionTemplate {
+	return &models.TransactionTemplate{
+		UserID:      userID,
+		Name:        in.Name,
+		Type:        in.Type,
+		CategoryID:  in.CategoryID,
+		Amount:      in.Amount,
+		Description: in.Description,
+		Tags:        in.Tags,
+		AccountID:   in.AccountID,
+	}
+}
+
+// GetAll lists the user's templates, most used first.
+func (ctrl *TemplateController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	templates, err := ctrl.service.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (ctrl *TemplateController) Create(c *gin.Context) {
+	var input templateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	template := input.toModel(userID)
+	if err := ctrl.service.Create(template); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (ctrl *TemplateController) Update(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input templateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template := input.toModel(userID)
+	template.ID = uint(id)
+	if err := ctrl.service.Update(template); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (ctrl *TemplateController) Delete(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.Delete(uint(id), userID); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted"})
+}
+
+// Use creates a transaction from the template. Every body field is optional and
+// overrides the template's value; the date defaults to now.
+func (ctrl *TemplateController) Use(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input struct {
+		Date        *time.Time `json:"date"`
+		Amount      *float64   `json:"amount"`
+		CategoryID  *uint      `json:"category_id"`
+		Description *string    `json:"description"`
+		Tags        *[]string  `json:"tags"`
+		AccountID   *uint      `json:"account_id"`
+		Status      string     `json:"status" binding:"omitempty,oneof=draft pending cleared"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	transaction, err := ctrl.service.Use(uint(id), userID, services.TemplateOverrides{
+		Date:        input.Date,
+		Amount:      input.Amount,
+		CategoryID:  input.CategoryID,
+		Description: input.Description,
+		Tags:        input.Tags,
+		AccountID:   input.AccountID,
+		Status:      input.Status,
+	})
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditCreate, "transaction", transaction.ID, nil, transaction)
+
+	c.Header("ETag", transactionETag(transaction))
+	c.JSON(http.StatusCreated, transaction)
+}
+
+func respondTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+	case errors.Is(err, services.ErrAccountNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+	case errors.Is(err, services.ErrCategoryKindMatch),
+		errors.Is(err, services.ErrTemplateName),
+		errors.Is(err, services.ErrTemplateType),
+		errors.Is(err, services.ErrTemplateAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Template request failed"})
+	}
+}
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/quickadd"
 	"github.com/antigravity/finance-tracker/services"
 	"github.com/gin-gonic/gin"
 )
@@ -13,20 +17,44 @@ import (
 type TransactionController struct {
 	service    *services.TransactionService
 	catService *services.CategoryService
+	accounts   *services.AccountService
+	audit      *services.AuditService
 }
 
-func NewTransactionController(service *services.TransactionService, catService *services.CategoryService) *TransactionController {
-	return &TransactionController{service, catService}
+func NewTransactionController(
+	service *services.TransactionService,
+	catService *services.CategoryService,
+	accounts *services.AccountService,
+	audit *services.AuditService,
+) *TransactionController {
+	return &TransactionController{service, catService, accounts, audit}
+}
+
+// checkAccount verifies an optional account belongs to the user, writing the
+// response when it does not.
+func (ctrl *TransactionController) checkAccount(c *gin.Context, accountID *uint, userID uint) bool {
+	if accountID == nil || *accountID == 0 {
+		return true
+	}
+	if _, err := ctrl.accounts.GetByID(*accountID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+		return false
+	}
+	return true
 }
 
 func (ctrl *TransactionController) Create(c *gin.Context) {
 	var input struct {
-		Type         string    `json:"type" binding:"required"`
+		Type         string    `json:"type" binding:"required,oneof=income expense"`
 		Amount       float64   `json:"amount" binding:"required"`
 		CategoryID   uint      `json:"category_id"`
 		CategoryName string    `json:"category_name"`
+		AccountID    *uint     `json:"account_id"`
 		Description  string    `json:"description"`
+		Payee        string    `json:"payee"`
+		Tags         []string  `json:"tags"`
 		Date         time.Time `json:"date" binding:"required"`
+		Status       string    `json:"status" binding:"omitempty,oneof=draft pending cleared"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -46,8 +74,14 @@ func (ctrl *TransactionController) Create(c *gin.Context) {
 		input.CategoryID = cat.ID
 	}
 
-	if input.CategoryID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Category is required"})
+	// Without a category the user's rules pick one, falling back to Uncategorized
+	if input.CategoryID != 0 {
+		if _, err := ctrl.catService.GetForTransaction(input.CategoryID, userID, input.Type); err != nil {
+			respondCategoryMismatch(c, err)
+			return
+		}
+	}
+	if !ctrl.checkAccount(c, input.AccountID, userID) {
 		return
 	}
 
@@ -56,14 +90,19 @@ func (ctrl *TransactionController) Create(c *gin.Context) {
 		Type:        input.Type,
 		Amount:      input.Amount,
 		CategoryID:  input.CategoryID,
+		AccountID:   accountOrNil(input.AccountID),
 		Description: input.Description,
+		Payee:       input.Payee,
+		Tags:        input.Tags,
 		Date:        input.Date,
+		Status:      input.Status,
 	}
 
 	if err := ctrl.service.Create(transaction); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
 		return
 	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditCreate, "transaction", transaction.ID, nil, transaction)
 
 	c.JSON(http.StatusCreated, transaction)
 }
@@ -76,6 +115,14 @@ func (ctrl *TransactionController) GetAll(c *gin.Context) {
 		id, _ := strconv.Atoi(catIDStr)
 		filter["category_id"] = uint(id)
 	}
+	if accountIDStr := c.Query("account_id"); accountIDStr != "" {
+		id, _ := strconv.Atoi(accountIDStr)
+		filter["account_id"] = uint(id)
+	}
+	// Drafts are left out unless status=draft or status=all
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
 	// Add more filters (date range) if needed
 
 	transactions, err := ctrl.service.GetAll(userID, filter)
@@ -91,8 +138,9 @@ func (ctrl *TransactionController) GetDashboard(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 	month, _ := strconv.Atoi(c.Query("month"))
 	year, _ := strconv.Atoi(c.Query("year"))
+	level, _ := strconv.Atoi(c.Query("level"))
 
-	data, err := ctrl.service.GetDashboard(userID, month, year)
+	data, err := ctrl.service.GetDashboard(userID, month, year, level)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dashboard data"})
 		return
@@ -101,29 +149,139 @@ func (ctrl *TransactionController) GetDashboard(c *gin.Context) {
 	c.JSON(http.StatusOK, data)
 }
 
+// QuickAdd parses short free text such as "kopi 25k kemarin". By default it only
+// returns the parsed draft; with create=true it also saves the transaction.
+func (ctrl *TransactionController) QuickAdd(c *gin.Context) {
+	var input struct {
+		Text   string `json:"text" binding:"required"`
+		Create bool   `json:"create"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	draft, err := ctrl.service.ParseQuick(userID, input.Text)
+	if errors.Is(err, quickadd.ErrNoAmount) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Could not find an amount, e.g. 25k or 25.000"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse transaction"})
+		return
+	}
+	if !input.Create {
+		c.JSON(http.StatusOK, gin.H{"draft": draft})
+		return
+	}
+
+	transaction := &models.Transaction{
+		UserID:      userID,
+		Type:        draft.Type,
+		Amount:      draft.Amount,
+		CategoryID:  draft.CategoryID,
+		Description: draft.Description,
+		Date:        draft.Date,
+	}
+	if err := ctrl.service.Create(transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditCreate, "transaction", transaction.ID, nil, transaction)
+
+	c.JSON(http.StatusCreated, gin.H{"draft": draft, "transaction": transaction})
+}
+
+// SuggestCategory ranks likely categories for a description and amount, e.g.
+// GET /transactions/suggest-category?description=GOFOOD*MCD&amount=56000&type=expense
+func (ctrl *TransactionController) SuggestCategory(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	amount, _ := strconv.ParseFloat(c.Query("amount"), 64)
+	txType := c.Query("type")
+	if txType != "" && txType != "income" && txType != "expense" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be income or expense"})
+		return
+	}
+
+	suggestions, err := ctrl.service.SuggestCategories(userID, c.Query("description"), amount, txType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
 func (ctrl *TransactionController) Delete(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	userID := c.MustGet("user_id").(uint)
 
+	before, _ := ctrl.service.GetByID(uint(id), userID)
 	if err := ctrl.service.Delete(uint(id), userID); err != nil {
+		if errors.Is(err, services.ErrTransactionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+			return
+		}
+		if errors.Is(err, services.ErrTransactionLocked) {
+			c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
 		return
 	}
+	if before != nil {
+		ctrl.audit.Record(actorFrom(c), userID, services.AuditDelete, "transaction", before.ID, before, nil)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted"})
 }
 
+func (ctrl *TransactionController) GetByID(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	transaction, err := ctrl.service.GetByID(uint(id), userID)
+	if errors.Is(err, services.ErrTransactionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return
+	}
+
+	etag := transactionETag(transaction)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
 func (ctrl *TransactionController) Update(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	userID := c.MustGet("user_id").(uint)
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+
 	var input struct {
-		Type         string    `json:"type" binding:"required"`
+		Type         string    `json:"type" binding:"required,oneof=income expense"`
 		Amount       float64   `json:"amount" binding:"required"`
 		CategoryID   uint      `json:"category_id"`
 		CategoryName string    `json:"category_name"`
+		AccountID    *uint     `json:"account_id"`
 		Description  string    `json:"description"`
+		Payee        string    `json:"payee"`
+		Tags         []string  `json:"tags"`
 		Date         time.Time `json:"date" binding:"required"`
+		Status       string    `json:"status" binding:"omitempty,oneof=draft pending cleared"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -145,6 +303,13 @@ func (ctrl *TransactionController) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Category is required"})
 		return
 	}
+	if _, err := ctrl.catService.GetForTransaction(input.CategoryID, userID, input.Type); err != nil {
+		respondCategoryMismatch(c, err)
+		return
+	}
+	if !ctrl.checkAccount(c, input.AccountID, userID) {
+		return
+	}
 
 	transaction := &models.Transaction{
 		ID:          uint(id),
@@ -152,14 +317,466 @@ func (ctrl *TransactionController) Update(c *gin.Context) {
 		Type:        input.Type,
 		Amount:      input.Amount,
 		CategoryID:  input.CategoryID,
+		AccountID:   accountOrNil(input.AccountID),
 		Description: input.Description,
+		Payee:       input.Payee,
+		Tags:        input.Tags,
 		Date:        input.Date,
+		Status:      input.Status,
 	}
 
-	if err := ctrl.service.Update(transaction); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+	before, _ := ctrl.service.GetByID(uint(id), userID)
+	if err := ctrl.service.Update(transaction, expectedVersion); err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "transaction", transaction.ID, before, transaction)
+
+	c.Header("ETag", transactionETag(transaction))
+	c.JSON(http.StatusOK, transaction)
+}
+
+// Patch updates only the fields present in the request body.
+func (ctrl *TransactionController) Patch(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+
+	var input transactionPatch
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before, err := ctrl.service.GetByID(uint(id), userID)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	fields, ok := ctrl.patchFields(c, before, input)
+	if !ok {
+		return
+	}
+
+	transaction, err := ctrl.service.Patch(uint(id), userID, fields, expectedVersion)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	if len(fields) > 0 {
+		ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "transaction", transaction.ID, before, transaction)
+	}
+
+	c.Header("ETag", transactionETag(transaction))
+	c.JSON(http.StatusOK, transaction)
+}
+
+// bulkFilter selects transactions for a bulk change. Dates are YYYY-MM-DD and
+// inclusive; drafts are left out unless status is draft or all.
+type bulkFilter struct {
+	Type       string `json:"type" binding:"omitempty,oneof=income expense"`
+	CategoryID uint   `json:"category_id"`
+	AccountID  uint   `json:"account_id"`
+	Status     string `json:"status"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+}
+
+// toMap converts the filter for TransactionService.Bulk. A filter with nothing
+// but a status is an error, so a bulk change never hits every transaction by
+// accident.
+func (f bulkFilter) toMap() (map[string]interface{}, error) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	filter := map[string]interface{}{}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	if f.CategoryID > 0 {
+		filter["category_id"] = f.CategoryID
+	}
+	if f.AccountID > 0 {
+		filter["account_id"] = f.AccountID
+	}
+	if f.StartDate != "" {
+		from, err := time.ParseInLocation("2006-01-02", f.StartDate, loc)
+		if err != nil {
+			return nil, errors.New("start_date must be YYYY-MM-DD")
+		}
+		filter["start_date"] = from
+	}
+	if f.EndDate != "" {
+		to, err := time.ParseInLocation("2006-01-02", f.EndDate, loc)
+		if err != nil {
+			return nil, errors.New("end_date must be YYYY-MM-DD")
+		}
+		// The repository compares with <=, so stop at the day's last microsecond
+		filter["end_date"] = to.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	if len(filter) == 0 {
+		return nil, errors.New("filter needs at least one field besides status")
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	return filter, nil
+}
+
+// Bulk applies one action to the transactions in ids or to those matching
+// filter, all in one database transaction. Reconciled transactions are skipped
+// and counted as locked.
+func (ctrl *TransactionController) Bulk(c *gin.Context) {
+	var input struct {
+		Action     string      `json:"action" binding:"required,oneof=recategorize retag change_date change_account delete"`
+		IDs        []uint      `json:"ids" binding:"max=1000"`
+		Filter     *bulkFilter `json:"filter"`
+		CategoryID uint        `json:"category_id"`
+		Tags       []string    `json:"tags"`
+		TagMode    string      `json:"tag_mode" binding:"omitempty,oneof=add remove set"`
+		Date       *time.Time  `json:"date"`
+		AccountID  *uint       `json:"account_id"` // 0 or null removes the account
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (len(input.IDs) > 0) == (input.Filter != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either ids or filter"})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	var filter map[string]interface{}
+	if input.Filter != nil {
+		var err error
+		if filter, err = input.Filter.toMap(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	change := services.BulkChange{Action: input.Action, Tags: input.Tags, TagMode: input.TagMode}
+	switch input.Action {
+	case services.BulkRecategorize:
+		category, err := ctrl.catService.GetByID(input.CategoryID, userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+			return
+		}
+		change.Category = category
+	case services.BulkRetag:
+		if len(input.Tags) == 0 && input.TagMode != "set" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tags are required"})
+			return
+		}
+	case services.BulkChangeDate:
+		if input.Date == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date is required"})
+			return
+		}
+		change.Date = *input.Date
+	case services.BulkChangeAccount:
+		if !ctrl.checkAccount(c, input.AccountID, userID) {
+			return
+		}
+		change.AccountID = accountOrNil(input.AccountID)
+	}
+
+	result, err := ctrl.service.Bulk(userID, input.IDs, filter, change)
+	switch {
+	case errors.Is(err, services.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Some transactions were not found"})
+		return
+	case errors.Is(err, services.ErrCategoryKindMatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transactions"})
+		return
+	}
+
+	actor := actorFrom(c)
+	for i := range result.Before {
+		if input.Action == services.BulkDelete {
+			ctrl.audit.Record(actor, userID, services.AuditDelete, "transaction", result.Before[i].ID, &result.Before[i], nil)
+		} else {
+			ctrl.audit.Record(actor, userID, services.AuditUpdate, "transaction", result.Before[i].ID, &result.Before[i], &result.After[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Unlock releases a reconciled transaction so Update and Delete accept it again.
+func (ctrl *TransactionController) Unlock(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	before, err := ctrl.service.GetByID(uint(id), userID)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	transaction, err := ctrl.service.Unlock(uint(id), userID)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	if before.Status != transaction.Status {
+		ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "transaction", transaction.ID, before, transaction)
+	}
+
+	c.Header("ETag", transactionETag(transaction))
+	c.JSON(http.StatusOK, transaction)
+}
+
+// GetInbox lists drafts from imports, email receipts and the Telegram bot awaiting review.
+func (ctrl *TransactionController) GetInbox(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	drafts, err := ctrl.service.GetDrafts(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inbox"})
+		return
+	}
+
+	c.JSON(http.StatusOK, drafts)
+}
+
+// ApproveDrafts approves a batch of drafts as they are, by default as cleared.
+func (ctrl *TransactionController) ApproveDrafts(c *gin.Context) {
+	var input struct {
+		IDs    []uint `json:"ids" binding:"required,min=1"`
+		Status string `json:"status" binding:"omitempty,oneof=pending cleared"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Status == "" {
+		input.Status = services.TransactionCleared
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	approved, err := ctrl.service.ApproveDrafts(input.IDs, userID, input.Status)
+	actor := actorFrom(c)
+	for i := range approved {
+		before := approved[i]
+		before.Status = services.TransactionDraft
+		ctrl.audit.Record(actor, userID, services.AuditUpdate, "transaction", approved[i].ID, &before, &approved[i])
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve drafts", "approved": len(approved)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"approved": len(approved), "skipped": len(input.IDs) - len(approved), "transactions": approved})
+}
+
+// ApproveDraft applies edits to one draft and approves it in the same write.
+// The body takes the same fields as PATCH /transactions/:id.
+func (ctrl *TransactionController) ApproveDraft(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	var input transactionPatch
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	status := services.TransactionCleared
+	if input.Status != nil {
+		if *input.Status != services.TransactionPending && *input.Status != services.TransactionCleared {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be pending or cleared"})
+			return
+		}
+		status = *input.Status
+	}
+
+	before, err := ctrl.service.GetDraft(uint(id), userID)
+	if errors.Is(err, services.ErrNotDraft) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	fields, ok := ctrl.patchFields(c, before, input)
+	if !ok {
+		return
+	}
+	fields["status"] = status
+
+	transaction, err := ctrl.service.Patch(uint(id), userID, fields, before.Version)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditUpdate, "transaction", transaction.ID, before, transaction)
 
 	c.JSON(http.StatusOK, transaction)
 }
+
+// RejectDrafts deletes a batch of drafts.
+func (ctrl *TransactionController) RejectDrafts(c *gin.Context) {
+	var input struct {
+		IDs []uint `json:"ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	rejected, err := ctrl.service.RejectDrafts(input.IDs, userID)
+	actor := actorFrom(c)
+	for i := range rejected {
+		ctrl.audit.Record(actor, userID, services.AuditDelete, "transaction", rejected[i].ID, &rejected[i], nil)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject drafts", "rejected": len(rejected)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rejected": len(rejected), "skipped": len(input.IDs) - len(rejected)})
+}
+
+// transactionPatch is a partial update; nil fields are left unchanged.
+type transactionPatch struct {
+	Type         *string    `json:"type"`
+	Amount       *float64   `json:"amount"`
+	CategoryID   *uint      `json:"category_id"`
+	CategoryName *string    `json:"category_name"`
+	AccountID    *uint      `json:"account_id"` // 0 removes the account
+	Description  *string    `json:"description"`
+	Payee        *string    `json:"payee"`
+	Tags         *[]string  `json:"tags"`
+	Date         *time.Time `json:"date"`
+	Status       *string    `json:"status"`
+}
+
+// patchFields validates a partial update against the transaction as it stands and
+// returns the columns to write. On failure it has already written the response.
+func (ctrl *TransactionController) patchFields(c *gin.Context, before *models.Transaction, input transactionPatch) (map[string]interface{}, bool) {
+	userID := before.UserID
+	fields := make(map[string]interface{})
+	txType := before.Type
+	if input.Type != nil {
+		if *input.Type != "income" && *input.Type != "expense" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be income or expense"})
+			return nil, false
+		}
+		fields["type"] = *input.Type
+		txType = *input.Type
+	}
+	if input.Amount != nil {
+		if *input.Amount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount cannot be zero"})
+			return nil, false
+		}
+		fields["amount"] = *input.Amount
+	}
+	if input.AccountID != nil {
+		if !ctrl.checkAccount(c, input.AccountID, userID) {
+			return nil, false
+		}
+		fields["account_id"] = accountOrNil(input.AccountID)
+	}
+	if input.Description != nil {
+		fields["description"] = *input.Description
+	}
+	if input.Payee != nil {
+		fields["payee"] = *input.Payee
+	}
+	if input.Tags != nil {
+		fields["tags"] = *input.Tags
+	}
+	if input.Date != nil {
+		fields["date"] = *input.Date
+	}
+	if input.Status != nil {
+		switch *input.Status {
+		case services.TransactionDraft, services.TransactionPending, services.TransactionCleared:
+			fields["status"] = *input.Status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be draft, pending or cleared"})
+			return nil, false
+		}
+	}
+	categoryID := before.CategoryID
+	if input.CategoryID != nil && *input.CategoryID != 0 {
+		categoryID = *input.CategoryID
+		fields["category_id"] = categoryID
+	} else if input.CategoryName != nil && *input.CategoryName != "" {
+		cat, err := ctrl.catService.GetOrCreateByName(userID, *input.CategoryName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve category"})
+			return nil, false
+		}
+		categoryID = cat.ID
+		fields["category_id"] = categoryID
+	}
+	// The category and type must still fit together, whichever of them changed
+	if _, ok := fields["category_id"]; ok || input.Type != nil {
+		if _, err := ctrl.catService.GetForTransaction(categoryID, userID, txType); err != nil {
+			respondCategoryMismatch(c, err)
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+// respondCategoryMismatch reports a category that is missing or does not fit the transaction type.
+func respondCategoryMismatch(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrCategoryKindMatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+}
+
+func respondUpdateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+	case errors.Is(err, services.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransactionLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+	}
+}
+
+// accountOrNil maps a missing or zero account ID to no account.
+func accountOrNil(id *uint) *uint {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
+
+func transactionETag(t *models.Transaction) string {
+	return fmt.Sprintf("\"%d\"", t.Version)
+}
+
+// ifMatchVersion reads the version from an If-Match header. It returns 0 when
+// the header is absent or "*", and false when it cannot be parsed.
+func ifMatchVersion(c *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	header = strings.Trim(strings.TrimPrefix(header, "W/"), "\"")
+	version, err := strconv.ParseUint(header, 10, 64)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return uint(version), true
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antigravity/finance-tracker/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TrashController struct {
+	service *services.TrashService
+	audit   *services.AuditService
+}
+
+func NewTrashController(service *services.TrashService, audit *services.AuditService) *TrashController {
+	return &TrashController{service, audit}
+}
+
+func (ctrl *TrashController) GetAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	trash, err := ctrl.service.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+func (ctrl *TrashController) RestoreTransaction(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	// Both fields are optional; an empty body restores the transaction as it was
+	var input struct {
+		RestoreCategory bool `json:"restore_category"`
+		CategoryID      uint `json:"category_id"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := ctrl.service.RestoreTransaction(uint(id), userID, input.RestoreCategory, input.CategoryID)
+	if err != nil {
+		respondTrashError(c, err, "Failed to restore transaction")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditRestore, "transaction", uint(id), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction restored"})
+}
+
+func (ctrl *TrashController) RestoreCategory(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.RestoreCategory(uint(id), userID); err != nil {
+		respondTrashError(c, err, "Failed to restore category")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditRestore, "category", uint(id), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category restored"})
+}
+
+func (ctrl *TrashController) PurgeTransaction(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.PurgeTransaction(uint(id), userID); err != nil {
+		respondTrashError(c, err, "Failed to purge transaction")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditPurge, "transaction", uint(id), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction permanently deleted"})
+}
+
+func (ctrl *TrashController) PurgeCategory(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.MustGet("user_id").(uint)
+
+	if err := ctrl.service.PurgeCategory(uint(id), userID); err != nil {
+		respondTrashError(c, err, "Failed to purge category")
+		return
+	}
+	ctrl.audit.Record(actorFrom(c), userID, services.AuditPurge, "category", uint(id), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category permanently deleted"})
+}
+
+func respondTrashError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrTrashItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCategoryNotFound), errors.Is(err, services.ErrCategoryKindMatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCategoryDeleted),
+		errors.Is(err, services.ErrCategoryNameTaken),
+		errors.Is(err, services.ErrCategoryReferenced):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
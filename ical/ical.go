@@ -0,0 +1,103 @@
+// Package ical writes iCalendar (RFC 5545) feeds of all-day events, enough for
+// calendar apps to subscribe to a list of due dates.
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type calendar apps expect for a feed.
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest content line allowed before folding, excluding CRLF.
+const maxLineOctets = 75
+
+type Calendar struct {
+	ProdID string // e.g. "-//Example//Finance Tracker//EN"
+	Name   string // Shown by apps that support X-WR-CALNAME
+	Events []Event
+}
+
+// Event is an all-day event. UID must stay the same across renders of the feed so
+// apps update the event instead of duplicating it.
+type Event struct {
+	UID         string
+	Date        time.Time // Only the year, month and day are used
+	Summary     string
+	Description string
+	// AlarmDaysBefore adds a display alarm that many days before the event
+	// when positive.
+	AlarmDaysBefore int
+}
+
+// Encode renders the calendar. now is the DTSTAMP of every event.
+func (c *Calendar) Encode(now time.Time) []byte {
+	var b strings.Builder
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+c.ProdID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+	for _, e := range c.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+escapeText(e.UID))
+		writeLine(&b, "DTSTAMP:"+stamp)
+		writeLine(&b, "DTSTART;VALUE=DATE:"+formatDate(e.Date))
+		writeLine(&b, "DTEND;VALUE=DATE:"+formatDate(e.Date.AddDate(0, 0, 1)))
+		writeLine(&b, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		writeLine(&b, "TRANSP:TRANSPARENT")
+		if e.AlarmDaysBefore > 0 {
+			writeLine(&b, "BEGIN:VALARM")
+			writeLine(&b, "ACTION:DISPLAY")
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Summary))
+			writeLine(&b, fmt.Sprintf("TRIGGER:-P%dD", e.AlarmDaysBefore))
+			writeLine(&b, "END:VALARM")
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11).
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writeLine writes a content line, folding it into 75 octet lines continued
+// with a leading space (RFC 5545 section 3.1) without splitting UTF-8 characters.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !startsRune(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the continuation line's length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func startsRune(c byte) bool {
+	return c&0xC0 != 0x80
+}
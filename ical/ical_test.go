@@ -0,0 +1,98 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	cal := Calendar{
+		ProdID: "-//Test//EN",
+		Name:   "Bills",
+		Events: []Event{{
+			UID:             "bill-1-20261025@test",
+			Date:            time.Date(2026, 10, 25, 0, 0, 0, 0, time.FixedZone("WIB", 7*3600)),
+			Summary:         "Electricity, PLN",
+			Description:     "Rp 350.000\nstatus: upcoming",
+			AlarmDaysBefore: 3,
+		}},
+	}
+	got := string(cal.Encode(time.Date(2026, 10, 19, 1, 2, 3, 0, time.UTC)))
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Test//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Bills",
+		"BEGIN:VEVENT",
+		"UID:bill-1-20261025@test",
+		"DTSTAMP:20261019T010203Z",
+		"DTSTART;VALUE=DATE:20261025",
+		"DTEND;VALUE=DATE:20261026",
+		`SUMMARY:Electricity\, PLN`,
+		`DESCRIPTION:Rp 350.000\nstatus: upcoming`,
+		"TRANSP:TRANSPARENT",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		`DESCRIPTION:Electricity\, PLN`,
+		"TRIGGER:-P3D",
+		"END:VALARM",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+	if got != want {
+		t.Errorf("Encode =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	cases := map[string]string{
+		"plain":        "plain",
+		`a\b`:          `a\\b`,
+		"a;b,c":        `a\;b\,c`,
+		"line\r\nnext": `line\nnext`,
+	}
+	for in, want := range cases {
+		if got := escapeText(in); got != want {
+			t.Errorf("escapeText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteLineFolds(t *testing.T) {
+	var b strings.Builder
+	line := "SUMMARY:" + strings.Repeat("é", 100)
+	writeLine(&b, line)
+
+	out := b.String()
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Fatalf("Line not terminated with CRLF: %q", out)
+	}
+	parts := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	if len(parts) < 2 {
+		t.Fatalf("Expected the line to be folded, got %q", out)
+	}
+	var unfolded strings.Builder
+	for i, p := range parts {
+		if len(p) > maxLineOctets {
+			t.Errorf("Part %d is %d octets", i, len(p))
+		}
+		if !utf8.ValidString(p) {
+			t.Errorf("Part %d splits a character: %q", i, p)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(p, " ") {
+				t.Errorf("Continuation %d lacks a leading space: %q", i, p)
+			}
+			p = p[1:]
+		}
+		unfolded.WriteString(p)
+	}
+	if unfolded.String() != line {
+		t.Errorf("Unfolded = %q, want %q", unfolded.String(), line)
+	}
+}
@@ -0,0 +1,219 @@
+// Package imap is a minimal IMAP4rev1 client with just enough of the protocol to
+// read unseen messages from one mailbox and mark them seen afterwards.
+package imap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config describes the mailbox to read. Addr is host:port.
+type Config struct {
+	Addr     string
+	Username string
+	Password string
+	Mailbox  string
+	TLS      bool
+}
+
+var literalPattern = regexp.MustCompile(`\{(\d+)\}$`)
+
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// response is one untagged server line, with any literals it carried.
+type response struct {
+	text     string
+	literals [][]byte
+}
+
+// Dial connects and reads the server greeting.
+func Dial(addr string, useTLS bool) (*Client, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("imap: unexpected greeting %q", strings.TrimSpace(greeting))
+	}
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// command sends one tagged command and collects the untagged responses before
+// the tagged completion, which must be OK.
+func (c *Client) command(cmd string) ([]response, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	c.conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, err
+	}
+
+	var responses []response
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(resp.text, tag+" ") {
+			status := strings.TrimPrefix(resp.text, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("imap: %s failed: %s", strings.Fields(cmd)[0], status)
+			}
+			return responses, nil
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// readResponse reads one logical line, following {n} literals into the lines
+// that continue after them.
+func (c *Client) readResponse() (response, error) {
+	var resp response
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		resp.text += line
+
+		m := literalPattern.FindStringSubmatch(line)
+		if m == nil {
+			return resp, nil
+		}
+		n, _ := strconv.Atoi(m[1])
+		literal := make([]byte, n)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.literals = append(resp.literals, literal)
+	}
+}
+
+func (c *Client) Login(username, password string) error {
+	_, err := c.command("LOGIN " + quote(username) + " " + quote(password))
+	return err
+}
+
+func (c *Client) Select(mailbox string) error {
+	_, err := c.command("SELECT " + quote(mailbox))
+	return err
+}
+
+// SearchUnseen returns the UIDs of messages without the \Seen flag.
+func (c *Client) SearchUnseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, resp := range responses {
+		if !strings.HasPrefix(resp.text, "* SEARCH") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(resp.text, "* SEARCH")) {
+			if uid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				uids = append(uids, uint32(uid))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Fetch returns the raw RFC 822 message without setting \Seen.
+func (c *Client) Fetch(uid uint32) ([]byte, error) {
+	responses, err := c.command(fmt.Sprintf("UID FETCH %d (BODY.PEEK[])", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range responses {
+		if strings.Contains(resp.text, "FETCH") && len(resp.literals) > 0 {
+			return resp.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("imap: message %d not found", uid)
+}
+
+func (c *Client) MarkSeen(uid uint32) error {
+	_, err := c.command(fmt.Sprintf(`UID STORE %d +FLAGS.SILENT (\Seen)`, uid))
+	return err
+}
+
+func (c *Client) Logout() error {
+	_, err := c.command("LOGOUT")
+	return err
+}
+
+// FetchUnseen passes each unseen message in the configured mailbox to handle and
+// marks it seen once handle succeeds, so failed messages are retried next time.
+func FetchUnseen(cfg Config, handle func(raw []byte) error) error {
+	c, err := Dial(cfg.Addr, cfg.TLS)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Login(cfg.Username, cfg.Password); err != nil {
+		return err
+	}
+	mailbox := cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if err := c.Select(mailbox); err != nil {
+		return err
+	}
+	uids, err := c.SearchUnseen()
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		raw, err := c.Fetch(uid)
+		if err != nil {
+			return err
+		}
+		if err := handle(raw); err != nil {
+			continue
+		}
+		if err := c.MarkSeen(uid); err != nil {
+			return err
+		}
+	}
+	return c.Logout()
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,147 @@
+package imap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer is a local IMAP stand-in holding one mailbox in memory. It speaks
+// only the commands the client sends.
+type fakeServer struct {
+	mu       sync.Mutex
+	password string
+	messages map[uint32]string
+	seen     map[uint32]bool
+	listener net.Listener
+}
+
+func newFakeServer(t *testing.T, messages map[uint32]string, seen ...uint32) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{password: "secret", messages: messages, seen: map[uint32]bool{}, listener: listener}
+	for _, uid := range seen {
+		s.seen[uid] = true
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK fake IMAP ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		tag, cmd := fields[0], strings.ToUpper(fields[1])
+		if cmd == "UID" && len(fields) > 2 {
+			cmd += " " + strings.ToUpper(fields[2])
+		}
+
+		s.mu.Lock()
+		switch cmd {
+		case "LOGIN":
+			if fields[3] != strconv.Quote(s.password) {
+				fmt.Fprintf(conn, "%s NO [AUTHENTICATIONFAILED] Invalid credentials\r\n", tag)
+			} else {
+				fmt.Fprintf(conn, "%s OK LOGIN completed\r\n", tag)
+			}
+		case "SELECT":
+			fmt.Fprintf(conn, "* %d EXISTS\r\n%s OK [READ-WRITE] SELECT completed\r\n", len(s.messages), tag)
+		case "UID SEARCH":
+			var uids []string
+			for uid := range s.messages {
+				if !s.seen[uid] {
+					uids = append(uids, strconv.Itoa(int(uid)))
+				}
+			}
+			sort.Strings(uids)
+			fmt.Fprintf(conn, "* SEARCH %s\r\n%s OK SEARCH completed\r\n", strings.Join(uids, " "), tag)
+		case "UID FETCH":
+			uid, _ := strconv.Atoi(fields[3])
+			msg := s.messages[uint32(uid)]
+			fmt.Fprintf(conn, "* 1 FETCH (UID %d BODY[] {%d}\r\n%s)\r\n%s OK FETCH completed\r\n", uid, len(msg), msg, tag)
+		case "UID STORE":
+			uid, _ := strconv.Atoi(fields[3])
+			s.seen[uint32(uid)] = true
+			fmt.Fprintf(conn, "%s OK STORE completed\r\n", tag)
+		case "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+			s.mu.Unlock()
+			return
+		default:
+			fmt.Fprintf(conn, "%s BAD unknown command\r\n", tag)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestFetchUnseen(t *testing.T) {
+	server := newFakeServer(t, map[uint32]string{
+		1: "Subject: old\r\n\r\nalready read",
+		2: "Subject: receipt\r\n\r\nTotal Rp25.000\r\n",
+		3: "Subject: broken\r\n\r\n{not a literal}",
+	}, 1)
+	cfg := Config{Addr: server.listener.Addr().String(), Username: "me", Password: "secret"}
+
+	var got []string
+	err := FetchUnseen(cfg, func(raw []byte) error {
+		got = append(got, string(raw))
+		if strings.Contains(string(raw), "broken") {
+			return errors.New("cannot parse")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchUnseen failed: %v", err)
+	}
+	if len(got) != 2 || got[0] != "Subject: receipt\r\n\r\nTotal Rp25.000\r\n" || got[1] != "Subject: broken\r\n\r\n{not a literal}" {
+		t.Fatalf("Expected the two unseen messages verbatim, got %q", got)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !server.seen[2] || server.seen[3] {
+		t.Errorf("Expected only the handled message to be marked seen, got %v", server.seen)
+	}
+}
+
+func TestFetchUnseenWrongPassword(t *testing.T) {
+	server := newFakeServer(t, map[uint32]string{})
+	cfg := Config{Addr: server.listener.Addr().String(), Username: "me", Password: "wrong"}
+
+	err := FetchUnseen(cfg, func([]byte) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "AUTHENTICATIONFAILED") {
+		t.Errorf("Expected a login failure, got %v", err)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := quote(`pa"ss\word`); got != `"pa\"ss\\word"` {
+		t.Errorf("quote = %s", got)
+	}
+}
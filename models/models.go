@@ -1,26 +1,39 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/antigravity/finance-tracker/utils"
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"size:100;not null" json:"name"`
-	Email     string         `gorm:"size:100;uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"size:255;not null" json:"-"`
-	Role      string         `gorm:"size:20;default:'user'" json:"role"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	Name       string         `gorm:"size:100;not null" json:"name"`
+	Email      string         `gorm:"size:100;uniqueIndex;not null" json:"email"`
+	Password   string         `gorm:"size:255;not null" json:"-"`
+	Role       string         `gorm:"size:20;default:'user'" json:"role"`
+	BudgetMode string         `gorm:"size:20;default:'simple'" json:"budget_mode"` // simple or envelope
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type Category struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    *uint          `json:"user_id"` // Nullable for default categories
+	UserID    *uint          `gorm:"uniqueIndex:idx_category_user_uuid" json:"user_id"` // Nullable for default categories
 	Name      string         `gorm:"size:100;not null" json:"name"`
+	ParentID  *uint          `gorm:"index" json:"parent_id"`                    // Nil for top-level categories
+	Kind      string         `gorm:"size:10;not null;default:both" json:"kind"` // income, expense or both
+	Icon      string         `gorm:"size:50" json:"icon"`
+	Color     string         `gorm:"size:7" json:"color"` // Hex, e.g. #0ea5e9
+	Archived  bool           `gorm:"default:false" json:"archived"`
+	Hidden    bool           `gorm:"-" json:"hidden"` // Per-user, filled in when listing
+	UUID      string         `gorm:"size:36;uniqueIndex:idx_category_user_uuid" json:"uuid"`
+	Version   uint           `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -28,16 +41,328 @@ type Category struct {
 
 type Transaction struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
-	UserID       uint           `gorm:"not null" json:"user_id"`
+	UserID       uint           `gorm:"not null;uniqueIndex:idx_transaction_user_uuid" json:"user_id"`
 	User         User           `gorm:"foreignKey:UserID" json:"-"`
 	Type         string         `gorm:"size:20;not null" json:"type"` // income or expense
 	CategoryID   uint           `gorm:"not null" json:"category_id"`
 	Category     Category       `gorm:"foreignKey:CategoryID" json:"category"`
 	Amount       float64        `gorm:"type:decimal(15,2);not null" json:"amount"`
 	Description  string         `gorm:"size:255" json:"description"`
+	AccountID    *uint          `gorm:"index" json:"account_id"`
+	PayeeID      *uint          `gorm:"index" json:"payee_id"`
+	Payee        string         `gorm:"size:100" json:"payee"` // Name of the linked payee, or free text
+	Tags         StringList     `gorm:"type:jsonb;default:'[]'" json:"tags"`
 	Date         time.Time      `gorm:"not null" json:"date"`
-	CategoryName string         `gorm:"-" json:"category_name"` // Flattens category name for frontend
+	Status       string         `gorm:"size:20;not null;default:cleared;index" json:"status"`      // draft, pending, cleared or reconciled
+	DuplicateOf  *uint          `json:"duplicate_of"`                                              // Likely duplicate of this transaction, flagged on create
+	CategoryName string         `gorm:"-" json:"category_name"`                                    // Flattens category name for frontend
+	UUID         string         `gorm:"size:36;uniqueIndex:idx_transaction_user_uuid" json:"uuid"` // Client-generated for offline sync
+	Version      uint           `gorm:"not null;default:1" json:"version"`                         // Incremented on every write
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// SyncTombstone remembers a row purged from the trash, so offline clients that
+// last synced before it was deleted still learn about the delete.
+type SyncTombstone struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Entity    string    `gorm:"size:20;not null" json:"entity"` // transaction or category
+	UUID      string    `gorm:"size:36;not null" json:"uuid"`
+	DeletedAt time.Time `gorm:"not null;index" json:"deleted_at"` // When the row was trashed, not purged
+}
+
+// Account is where money is held: a bank account, credit card, e-wallet or cash.
+type Account struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"not null;index" json:"user_id"`
+	Name           string    `gorm:"size:100;not null" json:"name"`
+	Type           string    `gorm:"size:20;not null;default:bank" json:"type"` // bank, credit_card, ewallet or cash
+	OpeningBalance float64   `gorm:"type:decimal(15,2);not null;default:0" json:"opening_balance"`
+	Balance        float64   `gorm:"-" json:"balance"` // Opening balance plus all non-draft transactions
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Reconciliation checks an account's cleared transactions against one bank statement.
+type Reconciliation struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	AccountID      uint       `gorm:"not null;index" json:"account_id"`
+	StatementDate  time.Time  `gorm:"not null" json:"statement_date"` // Last day the statement covers
+	ClosingBalance float64    `gorm:"type:decimal(15,2);not null" json:"closing_balance"`
+	Status         string     `gorm:"size:20;not null;default:open" json:"status"` // open or completed
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// DuplicateDismissal records that two transactions are not duplicates, so the
+// pair is not suggested again. TransactionID is the lower of the two IDs.
+type DuplicateDismissal struct {
+	UserID        uint      `gorm:"primaryKey" json:"user_id"`
+	TransactionID uint      `gorm:"primaryKey" json:"transaction_id"`
+	OtherID       uint      `gorm:"primaryKey" json:"other_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// HiddenCategory hides a default category from one user's pickers.
+type HiddenCategory struct {
+	UserID     uint      `gorm:"primaryKey" json:"user_id"`
+	CategoryID uint      `gorm:"primaryKey" json:"category_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	if c.UUID == "" {
+		c.UUID = utils.NewUUID()
+	}
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	return nil
+}
+
+func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
+	if t.UUID == "" {
+		t.UUID = utils.NewUUID()
+	}
+	if t.Version == 0 {
+		t.Version = 1
+	}
+	return nil
+}
+
+// Budget is the amount a user assigns to a category for one month, at most one
+// row per category and month. In simple mode it is a spending limit; in envelope
+// mode it is money moved from "ready to assign" into the category's envelope.
+type Budget struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index;uniqueIndex:idx_budget_period" json:"user_id"`
+	CategoryID uint           `gorm:"not null;uniqueIndex:idx_budget_period" json:"category_id"`
+	Category   Category       `gorm:"foreignKey:CategoryID" json:"-"`
+	Year       int            `gorm:"not null;uniqueIndex:idx_budget_period" json:"year"`
+	Month      int            `gorm:"not null;uniqueIndex:idx_budget_period" json:"month"`
+	Amount     float64        `gorm:"type:decimal(15,2);not null" json:"amount"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// AlertRule describes a condition that raises a notification when met.
+// Threshold is a percentage for budget rules and an amount for the others.
+type AlertRule struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index" json:"user_id"`
+	Type       string         `gorm:"size:30;not null" json:"type"` // budget, large_transaction, daily_spend, low_balance
+	CategoryID *uint          `json:"category_id"`                  // Optional, budget rules only
+	Threshold  float64        `gorm:"type:decimal(15,2);not null" json:"threshold"`
+	Channels   string         `gorm:"size:100" json:"channels"` // Comma separated extra channels: email, webhook, push
+	WebhookURL string         `gorm:"size:255" json:"webhook_url"`
+	Enabled    bool           `gorm:"default:true" json:"enabled"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Notification is an entry in the user's in-app inbox.
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	RuleID    *uint      `json:"rule_id"`
+	Type      string     `gorm:"size:30;not null" json:"type"`
+	Title     string     `gorm:"size:150;not null" json:"title"`
+	Body      string     `gorm:"size:500" json:"body"`
+	DedupKey  string     `gorm:"size:150;index" json:"-"` // Prevents the same alert firing twice
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PushSubscription is a browser Push API subscription for one user device.
+type PushSubscription struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index;uniqueIndex:idx_push_user_endpoint" json:"user_id"`
+	Endpoint   string     `gorm:"size:1000;not null;uniqueIndex:idx_push_user_endpoint" json:"endpoint"`
+	P256dh     string     `gorm:"size:200;not null" json:"-"`
+	Auth       string     `gorm:"size:100;not null" json:"-"`
+	Device     string     `gorm:"size:150" json:"device"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// VapidKey stores the generated VAPID key pair when none is configured in the environment.
+type VapidKey struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PublicKey  string    `gorm:"size:200;not null" json:"public_key"`
+	PrivateKey string    `gorm:"size:200;not null" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditLog is an append-only record of a change to a financial record.
+// Rows are never updated or deleted.
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	UserID     uint            `gorm:"not null;index" json:"user_id"` // Owner of the ledger the change was made in
+	ActorID    uint            `gorm:"not null" json:"actor_id"`
+	IP         string          `gorm:"size:45" json:"ip"`
+	Action     string          `gorm:"size:30;not null" json:"action"`
+	EntityType string          `gorm:"size:30;not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint            `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	Before     json.RawMessage `gorm:"type:jsonb" json:"before"`
+	After      json.RawMessage `gorm:"type:jsonb" json:"after"`
+	Changes    json.RawMessage `gorm:"type:jsonb" json:"changes"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
+}
+
+// Rule auto-categorizes transactions. Every condition that is set must match; the
+// highest-priority matching rule supplies the category, tags and payee.
+type Rule struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Priority   int        `gorm:"not null;default:0" json:"priority"` // Higher runs first
+	MatchType  string     `gorm:"size:10" json:"match_type"`          // contains, equals or regex on the description
+	Pattern    string     `gorm:"size:255" json:"pattern"`
+	MinAmount  *float64   `gorm:"type:decimal(15,2)" json:"min_amount"`
+	MaxAmount  *float64   `gorm:"type:decimal(15,2)" json:"max_amount"`
+	Type       string     `gorm:"size:20" json:"type"` // income, expense or empty for either
+	AccountID  *uint      `gorm:"index" json:"account_id"`
+	CategoryID *uint      `json:"category_id"`
+	Tags       StringList `gorm:"type:jsonb;default:'[]'" json:"tags"`
+	Payee      string     `gorm:"size:100" json:"payee"`
+	Enabled    bool       `gorm:"default:true" json:"enabled"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TransactionTemplate is a saved expense or income that repeats except for the
+// date, such as lunch or parking, entered again with one tap.
+type TransactionTemplate struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	Type        string     `gorm:"size:20;not null" json:"type"` // income or expense
+	CategoryID  uint       `gorm:"not null" json:"category_id"`
+	Amount      float64    `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Description string     `gorm:"size:255" json:"description"`
+	Tags        StringList `gorm:"type:jsonb;default:'[]'" json:"tags"`
+	AccountID   *uint      `json:"account_id"`
+	UsageCount  int        `gorm:"not null;default:0" json:"usage_count"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Bill is a recurring payment with a due date, such as electricity or a credit
+// card. Periods start in StartDate's month and repeat every IntervalMonths; each
+// falls due on DueDay, or the month's last day if shorter. A period is paid when
+// a matching expense is recorded near its due date.
+type Bill struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	UserID           uint      `gorm:"not null;index" json:"user_id"`
+	Name             string    `gorm:"size:100;not null" json:"name"`
+	Amount           float64   `gorm:"type:decimal(15,2);not null;default:0" json:"amount"` // Estimate; actual payments may vary
+	DueDay           int       `gorm:"not null" json:"due_day"`                             // 1-31
+	IntervalMonths   int       `gorm:"not null;default:1" json:"interval_months"`           // 1 monthly, 3 quarterly, 12 yearly
+	StartDate        time.Time `gorm:"not null" json:"start_date"`
+	PayeeID          *uint     `gorm:"index" json:"payee_id"`
+	Payee            string    `gorm:"size:100" json:"payee"`
+	CategoryID       *uint     `json:"category_id"`
+	AccountID        *uint     `json:"account_id"`
+	RemindDaysBefore int       `gorm:"not null;default:3" json:"remind_days_before"`
+	Channels         string    `gorm:"size:100" json:"channels"` // Comma separated extra reminder channels: email, push
+	Active           bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Payee is a merchant or counterparty. Aliases are other spellings that resolve to
+// it, e.g. "INDOMARET 123" or "indomaret pt" for Indomaret.
+type Payee struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	Name              string     `gorm:"size:100;not null" json:"name"`
+	Aliases           StringList `gorm:"type:jsonb;default:'[]'" json:"aliases"`
+	DefaultCategoryID *uint      `json:"default_category_id"` // Used when a transaction has no category
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TelegramLink connects a Telegram chat to a user so the bot can act for them.
+type TelegramLink struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	ChatID    int64     `gorm:"uniqueIndex;not null" json:"chat_id"`
+	Username  string    `gorm:"size:100" json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TelegramLinkCode is a one-time code the user sends to the bot to link their chat.
+type TelegramLinkCode struct {
+	Code      string    `gorm:"primaryKey;size:16" json:"code"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CalendarFeed is a user's iCalendar feed. The token in its URL is the only
+// credential, so calendar apps can subscribe without logging in. Only its
+// SHA-256 is stored; Token holds the plaintext just after it is issued.
+type CalendarFeed struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Token     string    `gorm:"-" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReceiptAddress is a user's secret plus-token for emailing receipts, as in
+// receipts+<token>@example.com. Mail is routed to a user only by this token.
+type ReceiptAddress struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	Token     string    `gorm:"size:32;uniqueIndex;not null" json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EmailReceipt records a receipt read from email and the draft transaction made from it.
+type EmailReceipt struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_receipt_message" json:"user_id"`
+	MessageID     string    `gorm:"size:255;not null;uniqueIndex:idx_receipt_message" json:"message_id"`
+	Sender        string    `gorm:"size:255" json:"sender"`
+	Subject       string    `gorm:"size:255" json:"subject"`
+	Merchant      string    `gorm:"size:100" json:"merchant"`
+	Amount        float64   `gorm:"not null" json:"amount"`
+	Date          time.Time `gorm:"not null" json:"date"`
+	Parser        string    `gorm:"size:30" json:"parser"`
+	TransactionID *uint     `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// StringList is a list of strings stored as a JSON array.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	raw, err := json.Marshal([]string(l))
+	return string(raw), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(l))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(l))
+	}
+	return fmt.Errorf("cannot scan %T into StringList", value)
+}
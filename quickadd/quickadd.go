@@ -0,0 +1,273 @@
+// Package quickadd parses short free-text transaction entries in Indonesian or
+// English, such as "kopi 25k kemarin" or "gaji 8,5jt", into their parts.
+package quickadd
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoAmount is returned when the text contains nothing that looks like an amount.
+var ErrNoAmount = errors.New("no amount found")
+
+// Result is a parsed entry. Words are the lowercase leftovers once the amount and
+// date are taken out, for matching against category names.
+type Result struct {
+	Amount       float64
+	Type         string // income or expense
+	Date         time.Time
+	Description  string
+	Words        []string
+	CategoryHint string // Default category name implied by a keyword, if any
+}
+
+var multipliers = map[string]float64{
+	"k": 1e3, "rb": 1e3, "ribu": 1e3,
+	"jt": 1e6, "juta": 1e6,
+}
+
+var (
+	amountPattern  = regexp.MustCompile(`^([+-]?)(?:rp\.?)?(\d+(?:[.,]\d+)*)(k|rb|ribu|jt|juta)?$`)
+	groupedPattern = regexp.MustCompile(`^\d{1,3}(?:[.,]\d{3})+$`)
+	// 25,000.50 or 1.250.000,75: thousands then a decimal part with the other separator
+	groupedDecimalPattern = regexp.MustCompile(`^(\d{1,3}(?:[.,]\d{3})+)([.,])(\d{1,2})$`)
+	datePattern           = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?$`)
+)
+
+var incomeWords = map[string]bool{
+	"gaji": true, "salary": true, "bonus": true, "thr": true, "pemasukan": true, "income": true,
+	"terima": true, "diterima": true, "received": true, "dapat": true, "refund": true,
+}
+
+var weekdays = map[string]time.Weekday{
+	"minggu": time.Sunday, "senin": time.Monday, "selasa": time.Tuesday, "rabu": time.Wednesday,
+	"kamis": time.Thursday, "jumat": time.Friday, "sabtu": time.Saturday,
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// categoryHints maps common words to the seeded default categories.
+var categoryHints = map[string]string{
+	"kopi": "Food & Beverage", "coffee": "Food & Beverage", "makan": "Food & Beverage",
+	"lunch": "Food & Beverage", "dinner": "Food & Beverage", "breakfast": "Food & Beverage",
+	"sarapan": "Food & Beverage", "jajan": "Food & Beverage", "snack": "Food & Beverage",
+	"gofood": "Food & Beverage", "grabfood": "Food & Beverage",
+	"bensin": "Transportation", "parkir": "Transportation", "ojek": "Transportation",
+	"ojol": "Transportation", "taxi": "Transportation", "taksi": "Transportation",
+	"tol": "Transportation", "krl": "Transportation", "mrt": "Transportation", "fuel": "Transportation",
+	"listrik": "Utilities", "pln": "Utilities", "pdam": "Utilities", "internet": "Utilities",
+	"wifi": "Utilities", "pulsa": "Utilities",
+	"kos": "Rent", "kost": "Rent", "sewa": "Rent", "rent": "Rent",
+	"obat": "Health", "dokter": "Health", "apotek": "Health", "doctor": "Health",
+	"bioskop": "Entertainment", "nonton": "Entertainment", "movie": "Entertainment", "netflix": "Entertainment",
+	"buku": "Education", "kursus": "Education", "course": "Education",
+	"belanja": "Shopping", "shopping": "Shopping",
+	"gaji": "Salary", "salary": "Salary", "bonus": "Bonus", "thr": "Bonus", "freelance": "Freelance",
+}
+
+// Parse reads text relative to now, whose location is used for dates. Entries are
+// expenses unless they carry an income keyword or a leading "+" on the amount.
+// Without a date word the date is now.
+func Parse(text string, now time.Time) (*Result, error) {
+	tokens := strings.Fields(strings.ToLower(text))
+	original := strings.Fields(text)
+	used := make([]bool, len(tokens))
+
+	result := &Result{Type: "expense"}
+	result.Date = parseDate(tokens, used, now)
+
+	// Prefer an explicit amount (suffix, Rp prefix or thousand separators) over a
+	// bare number, so "2 kopi 50k" is 50,000; among bare numbers take the largest.
+	best, bestExplicit := -1, false
+	var bestSign string
+	var bestSuffix bool
+	for i, token := range tokens {
+		if used[i] {
+			continue
+		}
+		amount, sign, explicit, ok := parseAmount(token)
+		if !ok {
+			continue
+		}
+		// "25 ribu" and "1,5 jt" put the suffix in its own token; "25k k" does not
+		m, hasSuffix := multipliers[nextToken(tokens, i)]
+		if hasSuffix && amountPattern.FindStringSubmatch(token)[3] != "" {
+			hasSuffix = false
+		}
+		if hasSuffix {
+			amount *= m
+			explicit = true
+		}
+		if best < 0 || (explicit && !bestExplicit) || (explicit == bestExplicit && !explicit && amount > result.Amount) {
+			best, bestExplicit, bestSign, bestSuffix = i, explicit, sign, hasSuffix
+			result.Amount = amount
+		}
+		if explicit {
+			break
+		}
+	}
+	if best < 0 || result.Amount <= 0 {
+		return nil, ErrNoAmount
+	}
+	used[best] = true
+	if bestSuffix {
+		used[best+1] = true
+	}
+	if bestSign == "+" {
+		result.Type = "income"
+	}
+
+	var words, description []string
+	for i, token := range tokens {
+		if used[i] || token == "rp" || token == "rp." {
+			continue
+		}
+		word := strings.Trim(token, ".,!?:;")
+		if incomeWords[word] {
+			result.Type = "income"
+		}
+		if hint, ok := categoryHints[word]; ok && result.CategoryHint == "" {
+			result.CategoryHint = hint
+		}
+		words = append(words, word)
+		description = append(description, original[i])
+	}
+	result.Words = words
+	result.Description = strings.Join(description, " ")
+	return result, nil
+}
+
+// parseAmount reads one token as an amount. explicit reports whether it carries a
+// suffix, an Rp prefix or thousand separators, i.e. is clearly meant as money.
+func parseAmount(token string) (value float64, sign string, explicit bool, ok bool) {
+	m := amountPattern.FindStringSubmatch(token)
+	if m == nil {
+		return 0, "", false, false
+	}
+	sign, number, suffix := m[1], m[2], m[3]
+	grouped := groupedPattern.MatchString(number)
+	decimal := groupedDecimalPattern.FindStringSubmatch(number)
+	if decimal != nil && strings.Contains(decimal[1], decimal[2]) {
+		decimal = nil // 1,250,5 mixes up the separators
+	}
+	explicit = suffix != "" || strings.HasPrefix(strings.TrimLeft(token, "+-"), "rp") || grouped || decimal != nil
+
+	var err error
+	switch {
+	case suffix == "" && grouped:
+		// 25.000 or 1,250,000: separators are thousands
+		value, err = strconv.ParseFloat(strings.NewReplacer(".", "", ",", "").Replace(number), 64)
+	case suffix == "" && decimal != nil:
+		value, err = strconv.ParseFloat(strings.NewReplacer(".", "", ",", "").Replace(decimal[1])+"."+decimal[3], 64)
+	default:
+		// 1,5jt or 2.5k: a single separator is the decimal point
+		if strings.Count(number, ".")+strings.Count(number, ",") > 1 {
+			return 0, "", false, false
+		}
+		value, err = strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	}
+	if err != nil {
+		return 0, "", false, false
+	}
+	if suffix != "" {
+		value *= multipliers[suffix]
+	}
+	return value, sign, explicit, true
+}
+
+// parseDate finds the first date expression among unused tokens, marks its tokens
+// used and returns the date. It returns now when there is none.
+func parseDate(tokens []string, used []bool, now time.Time) time.Time {
+	for i, token := range tokens {
+		if used[i] {
+			continue
+		}
+		next := nextToken(tokens, i)
+
+		switch {
+		case token == "kemarin" && next == "lusa":
+			used[i], used[i+1] = true, true
+			return now.AddDate(0, 0, -2)
+		case token == "kemarin" || token == "yesterday":
+			used[i] = true
+			return now.AddDate(0, 0, -1)
+		case token == "hari" && next == "ini", token == "today", token == "tadi":
+			used[i] = true
+			if token == "hari" {
+				used[i+1] = true
+			}
+			return now
+		case token == "minggu" && next == "lalu", token == "last" && next == "week":
+			used[i], used[i+1] = true, true
+			return now.AddDate(0, 0, -7)
+		}
+
+		// "3 hari lalu" / "3 days ago"
+		if n, err := strconv.Atoi(token); err == nil && n > 0 && i+2 < len(tokens) {
+			unit, ago := tokens[i+1], tokens[i+2]
+			if (unit == "hari" && ago == "lalu") || ((unit == "days" || unit == "day") && ago == "ago") {
+				used[i], used[i+1], used[i+2] = true, true, true
+				return now.AddDate(0, 0, -n)
+			}
+		}
+
+		// "senin lalu", "last monday" or a bare weekday
+		if token == "last" {
+			if day, ok := weekdays[next]; ok {
+				used[i], used[i+1] = true, true
+				return previousWeekday(now, day, false)
+			}
+		}
+		if day, ok := weekdays[token]; ok {
+			used[i] = true
+			if next == "lalu" {
+				used[i+1] = true
+				return previousWeekday(now, day, false)
+			}
+			return previousWeekday(now, day, true)
+		}
+
+		// 12/3 or 12/03/2024, day first
+		if m := datePattern.FindStringSubmatch(token); m != nil {
+			day, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			year := now.Year()
+			if m[3] != "" {
+				year, _ = strconv.Atoi(m[3])
+				if year < 100 {
+					year += 2000
+				}
+			}
+			date := time.Date(year, time.Month(month), day, now.Hour(), now.Minute(), 0, 0, now.Location())
+			if date.Day() != day || month < 1 || month > 12 {
+				continue
+			}
+			if m[3] == "" && date.After(now) {
+				date = date.AddDate(-1, 0, 0)
+			}
+			used[i] = true
+			return date
+		}
+	}
+	return now
+}
+
+// previousWeekday returns the most recent day before now falling on weekday, or
+// now itself when it matches and includeToday is set.
+func previousWeekday(now time.Time, day time.Weekday, includeToday bool) time.Time {
+	diff := (int(now.Weekday()) - int(day) + 7) % 7
+	if diff == 0 && !includeToday {
+		diff = 7
+	}
+	return now.AddDate(0, 0, -diff)
+}
+
+func nextToken(tokens []string, i int) string {
+	if i+1 < len(tokens) {
+		return tokens[i+1]
+	}
+	return ""
+}
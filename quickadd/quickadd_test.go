@@ -0,0 +1,63 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	// A Wednesday
+	now := time.Date(2024, 3, 13, 9, 30, 0, 0, loc)
+
+	cases := []struct {
+		text        string
+		amount      float64
+		txType      string
+		date        string
+		description string
+		hint        string
+	}{
+		{"kopi 25k kemarin", 25000, "expense", "2024-03-12", "kopi", "Food & Beverage"},
+		{"gaji 8,5jt", 8500000, "income", "2024-03-13", "gaji", "Salary"},
+		{"Makan siang Rp 45.000 senin lalu", 45000, "expense", "2024-03-11", "Makan siang", "Food & Beverage"},
+		{"bensin 150 ribu yesterday", 150000, "expense", "2024-03-12", "bensin", "Transportation"},
+		{"2 kopi 50rb", 50000, "expense", "2024-03-13", "2 kopi", "Food & Beverage"},
+		{"+1,250,000 freelance 3 hari lalu", 1250000, "income", "2024-03-10", "freelance", "Freelance"},
+		{"listrik 350000 5/3", 350000, "expense", "2024-03-05", "listrik", "Utilities"},
+		{"netflix 186k last friday", 186000, "expense", "2024-03-08", "netflix", "Entertainment"},
+		{"parkir 5k rabu", 5000, "expense", "2024-03-13", "parkir", "Transportation"},
+		{"kopi 25k k", 25000, "expense", "2024-03-13", "kopi k", "Food & Beverage"},
+		{"makan 25,000.50", 25000.5, "expense", "2024-03-13", "makan", "Food & Beverage"},
+		{"sewa 1.250.000,75", 1250000.75, "expense", "2024-03-13", "sewa", "Rent"},
+	}
+
+	for _, tc := range cases {
+		result, err := Parse(tc.text, now)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.text, err)
+			continue
+		}
+		if result.Amount != tc.amount {
+			t.Errorf("%q: expected amount %v, got %v", tc.text, tc.amount, result.Amount)
+		}
+		if result.Type != tc.txType {
+			t.Errorf("%q: expected type %s, got %s", tc.text, tc.txType, result.Type)
+		}
+		if got := result.Date.Format("2006-01-02"); got != tc.date {
+			t.Errorf("%q: expected date %s, got %s", tc.text, tc.date, got)
+		}
+		if result.Description != tc.description {
+			t.Errorf("%q: expected description %q, got %q", tc.text, tc.description, result.Description)
+		}
+		if result.CategoryHint != tc.hint {
+			t.Errorf("%q: expected hint %q, got %q", tc.text, tc.hint, result.CategoryHint)
+		}
+	}
+}
+
+func TestParseWithoutAmount(t *testing.T) {
+	if _, err := Parse("kopi kemarin", time.Now()); err != ErrNoAmount {
+		t.Errorf("Expected ErrNoAmount, got %v", err)
+	}
+}
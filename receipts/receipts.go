@@ -0,0 +1,338 @@
+// Package receipts reads emailed receipts: it decodes the MIME message into plain
+// text and extracts the merchant and total with a parser chosen by sender domain.
+package receipts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrNoTotal is returned when a parser cannot find the amount paid.
+var ErrNoTotal = errors.New("no total found in receipt")
+
+// Message is an email reduced to what parsers need. For forwarded mail, From is
+// the original sender found in the body and ForwardedBy the person who forwarded it.
+type Message struct {
+	MessageID   string
+	From        string
+	FromName    string
+	ForwardedBy string
+	Recipients  []string
+	Subject     string
+	Date        time.Time
+	Text        string
+}
+
+// Receipt is what a parser extracted. Parser names the parser that produced it.
+type Receipt struct {
+	Merchant string
+	Total    float64
+	Date     time.Time
+	Parser   string
+}
+
+// Parser extracts a receipt from a message.
+type Parser interface {
+	Parse(msg *Message) (*Receipt, error)
+}
+
+// ParserFunc adapts a function to the Parser interface.
+type ParserFunc func(msg *Message) (*Receipt, error)
+
+func (f ParserFunc) Parse(msg *Message) (*Receipt, error) {
+	return f(msg)
+}
+
+var (
+	amountText      = `(?:rp|idr)\.?\s*([\d.,]*\d)`
+	totalPattern    = regexp.MustCompile(`(?i)\btotal\b[^\n\d]{0,40}?` + amountText)
+	forwardPattern  = regexp.MustCompile(`(?i)(-{2,}\s*forwarded message\s*-{2,}|begin forwarded message:)`)
+	fromLinePattern = regexp.MustCompile(`(?im)^\s*(?:from|dari)\s*:\s*(.+)$`)
+	tagPattern      = regexp.MustCompile(`(?s)<[^>]*>`)
+	blockPattern    = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	breakPattern    = regexp.MustCompile(`(?i)<(br|/p|/div|/tr|/h\d|/li)[^>]*>`)
+	cellPattern     = regexp.MustCompile(`(?i)</t[dh]>`)
+	spacePattern    = regexp.MustCompile(`[ \t\x{a0}]+`)
+)
+
+// PatternParser reads receipts from one known sender. The first Totals pattern
+// that matches gives the amount in its first group.
+type PatternParser struct {
+	Name     string
+	Merchant string
+	Totals   []*regexp.Regexp
+}
+
+func (p *PatternParser) Parse(msg *Message) (*Receipt, error) {
+	for _, pattern := range p.Totals {
+		m := pattern.FindStringSubmatch(msg.Text)
+		if m == nil {
+			continue
+		}
+		if total, ok := ParseAmount(m[1]); ok && total > 0 {
+			return &Receipt{Merchant: p.Merchant, Total: total, Date: msg.Date, Parser: p.Name}, nil
+		}
+	}
+	return nil, ErrNoTotal
+}
+
+// Generic handles senders without a dedicated parser. It takes the largest
+// "total" amount in the text, which skips discounts and subtotals, and names the
+// merchant after the sender.
+var Generic Parser = ParserFunc(func(msg *Message) (*Receipt, error) {
+	best := 0.0
+	for _, m := range totalPattern.FindAllStringSubmatch(msg.Text, -1) {
+		if total, ok := ParseAmount(m[1]); ok && total > best {
+			best = total
+		}
+	}
+	if best == 0 {
+		return nil, ErrNoTotal
+	}
+	merchant := msg.FromName
+	if merchant == "" {
+		merchant = merchantFromAddress(msg.From)
+	}
+	return &Receipt{Merchant: merchant, Total: best, Date: msg.Date, Parser: "generic"}, nil
+})
+
+// totalsAfter builds one pattern per label, in order of preference.
+func totalsAfter(labels ...string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(labels)+1)
+	for _, label := range labels {
+		patterns = append(patterns, regexp.MustCompile(`(?i)\b`+label+`\b[^\n\d]{0,30}?`+amountText))
+	}
+	return append(patterns, totalPattern)
+}
+
+// Registry picks a parser by the sender's domain, falling back to Generic.
+type Registry struct {
+	parsers  map[string]Parser
+	fallback Parser
+}
+
+// NewRegistry returns a registry with the built-in parsers for common Indonesian
+// shops and ride-hailing apps.
+func NewRegistry() *Registry {
+	r := &Registry{parsers: map[string]Parser{}, fallback: Generic}
+	r.Register("gojek.com", &PatternParser{Name: "gojek", Merchant: "Gojek", Totals: totalsAfter("total pembayaran", "total payment", "total bayar")})
+	r.Register("grab.com", &PatternParser{Name: "grab", Merchant: "Grab", Totals: totalsAfter("total dibayar", "total paid")})
+	r.Register("tokopedia.com", &PatternParser{Name: "tokopedia", Merchant: "Tokopedia", Totals: totalsAfter("total tagihan", "total pembayaran", "total belanja")})
+	r.Register("shopee.co.id", &PatternParser{Name: "shopee", Merchant: "Shopee", Totals: totalsAfter("total pembayaran", "total payment")})
+	return r
+}
+
+// Register uses p for mail from domain and its subdomains.
+func (r *Registry) Register(domain string, p Parser) {
+	r.parsers[strings.ToLower(domain)] = p
+}
+
+// Parse runs the parser registered for the sender's domain, or the most specific
+// parent domain, and otherwise the generic one.
+func (r *Registry) Parse(msg *Message) (*Receipt, error) {
+	domain := msg.From[strings.LastIndex(msg.From, "@")+1:]
+	for domain != "" {
+		if p, ok := r.parsers[domain]; ok {
+			return p.Parse(msg)
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return r.fallback.Parse(msg)
+}
+
+// ReadMessage decodes a raw RFC 822 message, preferring its text/plain part and
+// converting HTML to text otherwise.
+func ReadMessage(raw []byte) (*Message, error) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	decoder := new(mime.WordDecoder)
+	header := func(key string) string {
+		value := parsed.Header.Get(key)
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			return decoded
+		}
+		return value
+	}
+
+	msg := &Message{
+		MessageID: strings.Trim(parsed.Header.Get("Message-Id"), "<> "),
+		Subject:   header("Subject"),
+	}
+	if date, err := parsed.Header.Date(); err == nil {
+		msg.Date = date
+	} else {
+		msg.Date = time.Now()
+	}
+	if from, err := mail.ParseAddress(header("From")); err == nil {
+		msg.From, msg.FromName = strings.ToLower(from.Address), from.Name
+	}
+	for _, key := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		addresses, err := mail.ParseAddressList(header(key))
+		if err != nil {
+			continue
+		}
+		for _, a := range addresses {
+			msg.Recipients = append(msg.Recipients, strings.ToLower(a.Address))
+		}
+	}
+
+	plain, htmlText, err := readBody(parsed.Header.Get("Content-Type"), parsed.Header.Get("Content-Transfer-Encoding"), parsed.Body)
+	if err != nil {
+		return nil, err
+	}
+	msg.Text = plain
+	if strings.TrimSpace(plain) == "" {
+		msg.Text = htmlToText(htmlText)
+	}
+
+	// A forwarded receipt names its real sender in the quoted header block
+	if loc := forwardPattern.FindStringIndex(msg.Text); loc != nil {
+		if m := fromLinePattern.FindStringSubmatch(msg.Text[loc[1]:]); m != nil {
+			if original, err := mail.ParseAddress(strings.TrimSpace(m[1])); err == nil {
+				msg.ForwardedBy = msg.From
+				msg.From, msg.FromName = strings.ToLower(original.Address), original.Name
+			}
+		}
+	}
+	return msg, nil
+}
+
+// readBody returns the first text/plain and text/html parts found, walking
+// nested multiparts.
+func readBody(contentType, encoding string, body io.Reader) (plain string, htmlText string, err error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return plain, htmlText, nil
+			}
+			if err != nil {
+				return plain, htmlText, err
+			}
+			p, h, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return plain, htmlText, err
+			}
+			if plain == "" {
+				plain = p
+			}
+			if htmlText == "" {
+				htmlText = h
+			}
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch mediaType {
+	case "text/plain":
+		return string(data), "", nil
+	case "text/html":
+		return "", string(data), nil
+	}
+	return "", "", nil
+}
+
+// newlineStripper drops line breaks so wrapped base64 decodes.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	kept := 0
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// htmlToText keeps one line per block element and table row so labels stay next
+// to their amounts.
+func htmlToText(s string) string {
+	s = blockPattern.ReplaceAllString(s, "")
+	s = breakPattern.ReplaceAllString(s, "\n")
+	s = cellPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(tagPattern.ReplaceAllString(s, ""))
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(spacePattern.ReplaceAllString(line, " ")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseAmount reads a rupiah amount written either way round: "25.000",
+// "25,000", "1.250.000,50" or "1,250,000.50". A last separator followed by
+// exactly three digits is a thousands separator.
+func ParseAmount(s string) (float64, bool) {
+	s = strings.Trim(s, ".,")
+	last := strings.LastIndexAny(s, ".,")
+	if last >= 0 && len(s)-last-1 != 3 {
+		integer := strings.NewReplacer(".", "", ",", "").Replace(s[:last])
+		s = integer + "." + s[last+1:]
+	} else {
+		s = strings.NewReplacer(".", "", ",", "").Replace(s)
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	return value, err == nil
+}
+
+// merchantFromAddress guesses a merchant name from the sender's domain, so
+// "noreply@email.alfamart.co.id" gives "Alfamart".
+func merchantFromAddress(address string) string {
+	labels := strings.Split(address[strings.LastIndex(address, "@")+1:], ".")
+	if len(labels) > 1 {
+		labels = labels[:len(labels)-1]
+	}
+	if n := len(labels); n > 1 && len(labels[n-1]) <= 3 {
+		switch labels[n-1] {
+		case "co", "com", "or", "ac", "net", "org", "web", "my":
+			labels = labels[:n-1]
+		}
+	}
+	name := []rune(labels[len(labels)-1])
+	if len(name) == 0 {
+		return ""
+	}
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
@@ -0,0 +1,122 @@
+package receipts
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func email(headers string, body string) []byte {
+	return []byte(strings.ReplaceAll(strings.TrimSpace(headers), "\n", "\r\n") + "\r\n\r\n" + body)
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]float64{
+		"25.000":       25000,
+		"25,000":       25000,
+		"1.250.000":    1250000,
+		"1.250.000,50": 1250000.5,
+		"1,250,000.50": 1250000.5,
+		"950":          950,
+	}
+	for input, want := range cases {
+		got, ok := ParseAmount(input)
+		if !ok || got != want {
+			t.Errorf("ParseAmount(%q) = %v, %v, want %v", input, got, ok, want)
+		}
+	}
+}
+
+func TestGojekPlainText(t *testing.T) {
+	raw := email(`
+From: Gojek <no-reply@invoicing.gojek.com>
+To: Budi <budi@example.com>
+Subject: Your GoRide trip
+Message-ID: <abc123@gojek.com>
+Date: Tue, 12 Mar 2024 08:15:00 +0700
+Content-Type: text/plain; charset=utf-8`, "Terima kasih!\nSubtotal Rp 20.000\nDiskon Rp 2.000\nTotal Pembayaran Rp 18.000\n")
+
+	msg, err := ReadMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MessageID != "abc123@gojek.com" || len(msg.Recipients) != 1 || msg.Recipients[0] != "budi@example.com" {
+		t.Errorf("Unexpected headers: %+v", msg)
+	}
+	receipt, err := NewRegistry().Parse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Parser != "gojek" || receipt.Merchant != "Gojek" || receipt.Total != 18000 || receipt.Date.Day() != 12 {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+}
+
+func TestHTMLQuotedPrintableInMultipart(t *testing.T) {
+	raw := email(`
+From: "Tokopedia" <noreply@tokopedia.com>
+To: budi@example.com
+Subject: =?UTF-8?B?UGVzYW5hbiBrYW11?=
+Date: Wed, 13 Mar 2024 20:00:00 +0700
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="b1"`, "--b1\r\n"+
+		"Content-Type: text/html; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: quoted-printable\r\n\r\n"+
+		"<html><head><style>td{color:red}</style></head><body><table>=\r\n"+
+		"<tr><td>Total Belanja</td><td>Rp&nbsp;150.000</td></tr>=\r\n"+
+		"<tr><td>Total Tagihan</td><td>Rp&nbsp;165.500</td></tr></table></body></html>\r\n"+
+		"--b1--\r\n")
+
+	msg, err := ReadMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Pesanan kamu" {
+		t.Errorf("Expected the encoded subject decoded, got %q", msg.Subject)
+	}
+	if strings.Contains(msg.Text, "color") || !strings.Contains(msg.Text, "Total Tagihan Rp 165.500") {
+		t.Errorf("Unexpected text from HTML:\n%s", msg.Text)
+	}
+	receipt, err := NewRegistry().Parse(msg)
+	if err != nil || receipt.Total != 165500 || receipt.Merchant != "Tokopedia" {
+		t.Errorf("Expected Total Tagihan to win, got %+v, %v", receipt, err)
+	}
+}
+
+func TestForwardedReceiptUsesOriginalSender(t *testing.T) {
+	raw := email(`
+From: Budi <budi@example.com>
+To: receipts@finance.example.com
+Subject: Fwd: Receipt
+Date: Thu, 14 Mar 2024 09:00:00 +0700
+Content-Type: text/plain`, "fyi\n\n---------- Forwarded message ---------\nFrom: Alfamart <struk@email.alfamart.co.id>\nSubject: Struk\n\nSubtotal Rp 40.000\nTOTAL Rp 42.500\nTotal Diskon Rp 1.000\n")
+
+	msg, err := ReadMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.From != "struk@email.alfamart.co.id" || msg.ForwardedBy != "budi@example.com" {
+		t.Errorf("Expected the original sender, got from=%q forwarded_by=%q", msg.From, msg.ForwardedBy)
+	}
+	receipt, err := NewRegistry().Parse(msg)
+	if err != nil || receipt.Parser != "generic" || receipt.Merchant != "Alfamart" || receipt.Total != 42500 {
+		t.Errorf("Unexpected receipt: %+v, %v", receipt, err)
+	}
+}
+
+func TestRegisterCustomParser(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("kopikenangan.id", &PatternParser{Name: "kopi-kenangan", Merchant: "Kopi Kenangan", Totals: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)grand total\s+([\d.]+)`),
+	}})
+
+	msg := &Message{From: "order@mail.kopikenangan.id", Text: "Grand Total 36.000"}
+	receipt, err := registry.Parse(msg)
+	if err != nil || receipt.Parser != "kopi-kenangan" || receipt.Total != 36000 {
+		t.Errorf("Expected the subdomain to use the registered parser, got %+v, %v", receipt, err)
+	}
+
+	if _, err := registry.Parse(&Message{From: "news@example.com", Text: "Promo hari ini!"}); err != ErrNoTotal {
+		t.Errorf("Expected ErrNoTotal, got %v", err)
+	}
+}
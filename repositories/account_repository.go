@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+// signedAmount counts income up and expenses down, for balances.
+const signedAmount = "COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE -amount END), 0)"
+
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db}
+}
+
+func (r *AccountRepository) Create(account *models.Account) error {
+	return r.db.Create(account).Error
+}
+
+func (r *AccountRepository) Update(account *models.Account) error {
+	return r.db.Save(account).Error
+}
+
+// Delete removes the account along with its reconciliation history, unlinks
+// templates and bills that pointed at it and disables rules conditioned on it.
+func (r *AccountRepository) Delete(id uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("account_id = ? AND user_id = ?", id, userID).Delete(&models.Reconciliation{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.TransactionTemplate{}, &models.Bill{}} {
+			err := tx.Model(model).Where("account_id = ? AND user_id = ?", id, userID).Update("account_id", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		// Without the account condition these rules would match far more, so they are switched off
+		err := tx.Model(&models.Rule{}).Where("account_id = ? AND user_id = ?", id, userID).
+			Updates(map[string]interface{}{"account_id": nil, "enabled": false}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Account{}).Error
+	})
+}
+
+func (r *AccountRepository) FindByID(id uint, userID uint) (*models.Account, error) {
+	var account models.Account
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&account).Error
+	return &account, err
+}
+
+func (r *AccountRepository) FindAll(userID uint) ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.db.Where("user_id = ?", userID).Order("name asc").Find(&accounts).Error
+	return accounts, err
+}
+
+// Balances returns the net of each account's non-draft transactions, by account ID.
+func (r *AccountRepository) Balances(userID uint) (map[uint]float64, error) {
+	var results []struct {
+		AccountID uint
+		Total     float64
+	}
+	err := r.db.Model(&models.Transaction{}).
+		Select("account_id, "+signedAmount+" as total").
+		Where("user_id = ? AND account_id IS NOT NULL", userID).
+		Where(notDraft).
+		Group("account_id").
+		Scan(&results).Error
+
+	balances := make(map[uint]float64, len(results))
+	for _, res := range results {
+		balances[res.AccountID] = res.Total
+	}
+	return balances, err
+}
+
+// CountTransactions counts the account's transactions, including deleted ones still in the trash.
+func (r *AccountRepository) CountTransactions(id uint, userID uint) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Transaction{}).
+		Where("account_id = ? AND user_id = ?", id, userID).
+		Count(&count).Error
+	return count, err
+}
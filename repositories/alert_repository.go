@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type AlertRepository struct {
+	db *gorm.DB
+}
+
+func NewAlertRepository(db *gorm.DB) *AlertRepository {
+	return &AlertRepository{db}
+}
+
+func (r *AlertRepository) Create(rule *models.AlertRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *AlertRepository) Update(rule *models.AlertRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *AlertRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.AlertRule{}).Error
+}
+
+func (r *AlertRepository) FindByID(id uint, userID uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&rule).Error
+	return &rule, err
+}
+
+func (r *AlertRepository) FindAll(userID uint) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	err := r.db.Where("user_id = ?", userID).Order("id asc").Find(&rules).Error
+	return rules, err
+}
+
+func (r *AlertRepository) FindEnabled(userID uint) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	err := r.db.Where("user_id = ? AND enabled = ?", userID, true).Find(&rules).Error
+	return rules, err
+}
+
+// FindUserIDsWithRules returns every user that has at least one enabled rule.
+func (r *AlertRepository) FindUserIDsWithRules() ([]uint, error) {
+	var userIDs []uint
+	err := r.db.Model(&models.AlertRule{}).Where("enabled = ?", true).Distinct().Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
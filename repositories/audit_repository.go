@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db}
+}
+
+func (r *AuditRepository) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *AuditRepository) FindByEntity(userID uint, entityType string, entityID uint) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	err := r.db.Where("user_id = ? AND entity_type = ? AND entity_id = ?", userID, entityType, entityID).
+		Order("created_at asc, id asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *AuditRepository) FindAll(userID uint, filter map[string]interface{}) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	query := r.db.Where("user_id = ?", userID)
+
+	if entityType, ok := filter["entity_type"].(string); ok && entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID, ok := filter["entity_id"].(uint); ok && entityID > 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if action, ok := filter["action"].(string); ok && action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if startDate, ok := filter["start_date"].(time.Time); ok {
+		query = query.Where("created_at >= ?", startDate)
+	}
+	if endDate, ok := filter["end_date"].(time.Time); ok {
+		query = query.Where("created_at <= ?", endDate)
+	}
+
+	limit := 100
+	if l, ok := filter["limit"].(int); ok && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	err := query.Order("created_at desc, id desc").Limit(limit).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type BillRepository struct {
+	db *gorm.DB
+}
+
+func NewBillRepository(db *gorm.DB) *BillRepository {
+	return &BillRepository{db}
+}
+
+func (r *BillRepository) Create(bill *models.Bill) error {
+	return r.db.Create(bill).Error
+}
+
+func (r *BillRepository) Update(bill *models.Bill) error {
+	return r.db.Save(bill).Error
+}
+
+func (r *BillRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Bill{}).Error
+}
+
+func (r *BillRepository) FindByID(id uint, userID uint) (*models.Bill, error) {
+	var bill models.Bill
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&bill).Error
+	return &bill, err
+}
+
+func (r *BillRepository) FindAll(userID uint) ([]models.Bill, error) {
+	var bills []models.Bill
+	err := r.db.Where("user_id = ?", userID).Order("due_day asc, name asc").Find(&bills).Error
+	return bills, err
+}
+
+// FindActive returns every user's active bills, for reminders.
+func (r *BillRepository) FindActive() ([]models.Bill, error) {
+	var bills []models.Bill
+	err := r.db.Where("active = ?", true).Order("user_id").Find(&bills).Error
+	return bills, err
+}
+
+// FindPayments returns expenses between from and to that could pay the bill: to
+// its payee if it has one, otherwise in its category. With an account set, only
+// that account's expenses and those without an account count. Drafts are left out.
+func (r *BillRepository) FindPayments(bill *models.Bill, from, to time.Time) ([]models.Transaction, error) {
+	query := r.db.Where("user_id = ? AND type = 'expense' AND date >= ? AND date < ?", bill.UserID, from, to).
+		Where(notDraft)
+	switch {
+	case bill.PayeeID != nil:
+		query = query.Where("payee_id = ?", *bill.PayeeID)
+	case bill.CategoryID != nil:
+		query = query.Where("category_id = ?", *bill.CategoryID)
+	default:
+		return nil, nil
+	}
+	if bill.AccountID != nil {
+		query = query.Where("(account_id = ? OR account_id IS NULL)", *bill.AccountID)
+	}
+
+	var transactions []models.Transaction
+	err := query.Order("date asc, id asc").Find(&transactions).Error
+	return transactions, err
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BudgetRepository struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) *BudgetRepository {
+	return &BudgetRepository{db}
+}
+
+// MonthlyTotal is a per-category sum for one calendar month.
+type MonthlyTotal struct {
+	Year       int
+	Month      int
+	CategoryID uint
+	Total      float64
+}
+
+// Upsert sets the assigned amount for a category and month, creating the row if
+// needed. It is a single statement so concurrent calls cannot create duplicates,
+// and it revives a budget that was deleted for that month.
+func (r *BudgetRepository) Upsert(b *models.Budget) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "category_id"}, {Name: "year"}, {Name: "month"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"amount":     gorm.Expr("excluded.amount"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+			"deleted_at": nil,
+		}),
+	}).Create(b).Error
+}
+
+func (r *BudgetRepository) FindByPeriod(userID uint, year int, month int) ([]models.Budget, error) {
+	var budgets []models.Budget
+	err := r.db.Where("user_id = ? AND year = ? AND month = ?", userID, year, month).Find(&budgets).Error
+	return budgets, err
+}
+
+// GetAssignedTotals returns assigned amounts per category and month up to and including the given month.
+func (r *BudgetRepository) GetAssignedTotals(userID uint, year int, month int) ([]MonthlyTotal, error) {
+	var results []MonthlyTotal
+	err := r.db.Model(&models.Budget{}).
+		Select("year, month, category_id, sum(amount) as total").
+		Where("user_id = ? AND (year < ? OR (year = ? AND month <= ?))", userID, year, year, month).
+		Group("year, month, category_id").
+		Scan(&results).Error
+	return results, err
+}
+
+func (r *BudgetRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Budget{}).Error
+}
+
+func (r *BudgetRepository) FindByCategory(userID uint, categoryID uint, year int, month int) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.Where("user_id = ? AND category_id = ? AND year = ? AND month = ?",
+		userID, categoryID, year, month).First(&budget).Error
+	return &budget, err
+}
+
+func (r *BudgetRepository) FindByID(id uint, userID uint) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error
+	return &budget, err
+}
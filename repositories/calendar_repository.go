@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type CalendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) *CalendarRepository {
+	return &CalendarRepository{db}
+}
+
+// Replace stores the user's feed, dropping any earlier one so its URL stops working.
+func (r *CalendarRepository) Replace(feed *models.CalendarFeed) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", feed.UserID).Delete(&models.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(feed).Error
+	})
+}
+
+func (r *CalendarRepository) FindByUser(userID uint) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.db.Where("user_id = ?", userID).First(&feed).Error
+	return &feed, err
+}
+
+func (r *CalendarRepository) FindByTokenHash(hash string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.db.Where("token_hash = ?", hash).First(&feed).Error
+	return &feed, err
+}
+
+// DeleteByUser removes the user's feed and reports whether there was one.
+func (r *CalendarRepository) DeleteByUser(userID uint) (bool, error) {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.CalendarFeed{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,8 +1,12 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"github.com/antigravity/finance-tracker/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CategoryRepository struct {
@@ -23,14 +27,231 @@ func (r *CategoryRepository) FindAll(userID uint) ([]models.Category, error) {
 	return categories, err
 }
 
+// FindVisible lists categories for pickers, leaving out archived categories and
+// defaults the user has hidden unless asked to include them. A non-empty kind keeps
+// only categories of that kind or "both".
+func (r *CategoryRepository) FindVisible(userID uint, includeArchived bool, includeHidden bool, kind string) ([]models.Category, error) {
+	var categories []models.Category
+	query := r.db.Where("(user_id = ? OR user_id IS NULL)", userID)
+	if kind != "" {
+		query = query.Where("kind IN ?", []string{kind, "both"})
+	}
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	if !includeHidden {
+		query = query.Where("id NOT IN (?)",
+			r.db.Model(&models.HiddenCategory{}).Select("category_id").Where("user_id = ?", userID))
+	}
+	err := query.Order("name asc").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepository) FindHiddenIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.HiddenCategory{}).Where("user_id = ?", userID).Pluck("category_id", &ids).Error
+	return ids, err
+}
+
+func (r *CategoryRepository) Hide(userID uint, categoryID uint) error {
+	return r.db.Where(models.HiddenCategory{UserID: userID, CategoryID: categoryID}).
+		FirstOrCreate(&models.HiddenCategory{}).Error
+}
+
+func (r *CategoryRepository) Unhide(userID uint, categoryID uint) error {
+	return r.db.Where("user_id = ? AND category_id = ?", userID, categoryID).Delete(&models.HiddenCategory{}).Error
+}
+
+// CountByName counts categories visible to the user with the same name, ignoring case and excludeID.
+func (r *CategoryRepository) CountByName(userID uint, name string, excludeID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).
+		Where("(user_id = ? OR user_id IS NULL) AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, excludeID).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateFields writes the given columns and bumps the version.
+func (r *CategoryRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updates["version"] = gorm.Expr("version + 1")
+	return r.db.Model(&models.Category{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// CountTransactionsByType counts the user's transactions of a type in the category,
+// trashed ones included since they can be restored.
+func (r *CategoryRepository) CountTransactionsByType(id uint, userID uint, txType string) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Transaction{}).
+		Where("category_id = ? AND user_id = ? AND type = ?", id, userID, txType).
+		Count(&count).Error
+	return count, err
+}
+
 func (r *CategoryRepository) FindByID(id uint) (*models.Category, error) {
 	var category models.Category
 	err := r.db.First(&category, id).Error
 	return &category, err
 }
 
-func (r *CategoryRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Category{}, id).Error
+// CountUsage counts the user's rows that reference the category: transactions
+// (including trashed ones), budgets, alert rules, templates, bills, rules and
+// payee defaults.
+func (r *CategoryRepository) CountUsage(id uint, userID uint) (int64, error) {
+	var total int64
+	err := r.db.Unscoped().Model(&models.Transaction{}).
+		Where("category_id = ? AND user_id = ?", id, userID).
+		Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, model := range []interface{}{&models.Budget{}, &models.AlertRule{}, &models.TransactionTemplate{}, &models.Bill{}, &models.Rule{}} {
+		var count int64
+		if err := r.db.Model(model).Where("category_id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	var payees int64
+	err = r.db.Model(&models.Payee{}).Where("default_category_id = ? AND user_id = ?", id, userID).Count(&payees).Error
+	return total + payees, err
+}
+
+// ErrVersionChanged means a row was written by someone else since the caller read it.
+var ErrVersionChanged = errors.New("version changed")
+
+// DeleteAndReassign moves the user's transactions, budgets, alert rules, templates,
+// bills, rules and payee defaults from the category to replacementID, lifts its
+// subcategories one level and soft-deletes the category, all in one DB transaction.
+// Budgets for a month the replacement already has are added to the existing amount.
+// It returns the moved transactions as they were before.
+func (r *CategoryRepository) DeleteAndReassign(id uint, userID uint, replacementID uint) ([]models.Transaction, error) {
+	return r.deleteAndReassign(id, userID, replacementID, 0)
+}
+
+// DeleteAndReassignIfVersion is DeleteAndReassign for a category still at version,
+// checked under a row lock. It returns ErrVersionChanged and changes nothing otherwise.
+func (r *CategoryRepository) DeleteAndReassignIfVersion(id uint, userID uint, replacementID uint, version uint) ([]models.Transaction, error) {
+	return r.deleteAndReassign(id, userID, replacementID, version)
+}
+
+func (r *CategoryRepository) deleteAndReassign(id uint, userID uint, replacementID uint, version uint) ([]models.Transaction, error) {
+	var moved []models.Transaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
+			return err
+		}
+		if version != 0 && category.Version != version {
+			return ErrVersionChanged
+		}
+
+		if replacementID != 0 {
+			err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("category_id = ? AND user_id = ?", id, userID).
+				Find(&moved).Error
+			if err != nil {
+				return err
+			}
+			if len(moved) > 0 {
+				err := tx.Unscoped().Model(&models.Transaction{}).
+					Where("id IN ?", transactionIDs(moved)).
+					Updates(map[string]interface{}{"category_id": replacementID, "version": gorm.Expr("version + 1")}).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := mergeBudgets(tx, id, userID, replacementID); err != nil {
+				return err
+			}
+
+			err = tx.Model(&models.AlertRule{}).
+				Where("category_id = ? AND user_id = ?", id, userID).
+				Update("category_id", replacementID).Error
+			if err != nil {
+				return err
+			}
+			for _, model := range []interface{}{&models.TransactionTemplate{}, &models.Bill{}} {
+				err := tx.Model(model).Where("category_id = ? AND user_id = ?", id, userID).Update("category_id", replacementID).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Rules and payee defaults follow the replacement, or stop setting a category without one
+		var replacement interface{}
+		if replacementID != 0 {
+			replacement = replacementID
+		}
+		err := tx.Model(&models.Rule{}).Where("category_id = ? AND user_id = ?", id, userID).Update("category_id", replacement).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Payee{}).Where("default_category_id = ? AND user_id = ?", id, userID).Update("default_category_id", replacement).Error
+		if err != nil {
+			return err
+		}
+
+		// Subcategories move up to the deleted category's own parent
+		err = tx.Model(&models.Category{}).
+			Where("parent_id = ?", id).
+			Updates(map[string]interface{}{"parent_id": category.ParentID, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Category{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")}).Error
+	})
+	return moved, err
+}
+
+func mergeBudgets(tx *gorm.DB, fromID uint, userID uint, toID uint) error {
+	var budgets []models.Budget
+	if err := tx.Where("category_id = ? AND user_id = ?", fromID, userID).Find(&budgets).Error; err != nil {
+		return err
+	}
+
+	for _, b := range budgets {
+		var target models.Budget
+		err := tx.Where("category_id = ? AND user_id = ? AND year = ? AND month = ?", toID, userID, b.Year, b.Month).
+			First(&target).Error
+		if err == gorm.ErrRecordNotFound {
+			// A deleted budget for the month would clash with the moved one
+			err := tx.Unscoped().Where("category_id = ? AND user_id = ? AND year = ? AND month = ? AND deleted_at IS NOT NULL",
+				toID, userID, b.Year, b.Month).Delete(&models.Budget{}).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&b).Update("category_id", toID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&target).Update("amount", target.Amount+b.Amount).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&b).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *CategoryRepository) FindDefaultByName(name string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Where("user_id IS NULL AND name = ?", name).First(&category).Error
+	return &category, err
 }
 
 func (r *CategoryRepository) GetOrCreateByName(userID uint, name string) (*models.Category, error) {
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DuplicateRepository struct {
+	db *gorm.DB
+}
+
+func NewDuplicateRepository(db *gorm.DB) *DuplicateRepository {
+	return &DuplicateRepository{db}
+}
+
+// FindNear returns the user's transactions of the same type and amount dated
+// between from and to, drafts included.
+func (r *DuplicateRepository) FindNear(userID uint, txType string, amount float64, from, to time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Where("user_id = ? AND type = ? AND amount = ? AND date BETWEEN ? AND ?", userID, txType, amount, from, to).
+		Order("date desc").
+		Limit(20).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// FindSince returns the user's transactions dated from since, grouped by type
+// and amount and ordered by date within each group.
+func (r *DuplicateRepository) FindSince(userID uint, since time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Preload("Category").
+		Where("user_id = ? AND date >= ?", userID, since).
+		Order("type, amount, date, id").
+		Find(&transactions).Error
+	for i := range transactions {
+		transactions[i].CategoryName = transactions[i].Category.Name
+	}
+	return transactions, err
+}
+
+// Dismiss stores the pair and clears the flag either side set on the other.
+func (r *DuplicateRepository) Dismiss(dismissal *models.DuplicateDismissal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(dismissal).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Transaction{}).
+			Where("user_id = ? AND ((id = ? AND duplicate_of = ?) OR (id = ? AND duplicate_of = ?))",
+				dismissal.UserID, dismissal.TransactionID, dismissal.OtherID, dismissal.OtherID, dismissal.TransactionID).
+			Updates(map[string]interface{}{"duplicate_of": nil, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+func (r *DuplicateRepository) FindDismissals(userID uint) ([]models.DuplicateDismissal, error) {
+	var dismissals []models.DuplicateDismissal
+	err := r.db.Where("user_id = ?", userID).Find(&dismissals).Error
+	return dismissals, err
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db}
+}
+
+// CreateOnce stores the notification unless one with the same dedup key already exists.
+// It reports whether a new row was created.
+func (r *NotificationRepository) CreateOnce(n *models.Notification) (bool, error) {
+	if n.DedupKey != "" {
+		var count int64
+		err := r.db.Model(&models.Notification{}).
+			Where("user_id = ? AND dedup_key = ?", n.UserID, n.DedupKey).
+			Count(&count).Error
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+	if err := r.db.Create(n).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *NotificationRepository) FindAll(userID uint, unreadOnly bool) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("created_at desc").Limit(100).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *NotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *NotificationRepository) MarkRead(id uint, userID uint) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *NotificationRepository) MarkUnread(id uint, userID uint) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", nil).Error
+}
+
+func (r *NotificationRepository) MarkAllRead(userID uint) error {
+	return r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayeeRepository struct {
+	db *gorm.DB
+}
+
+func NewPayeeRepository(db *gorm.DB) *PayeeRepository {
+	return &PayeeRepository{db}
+}
+
+// PayeeTotal is one row of the top-payees report.
+type PayeeTotal struct {
+	PayeeID uint    `json:"payee_id"`
+	Name    string  `json:"name"`
+	Total   float64 `json:"total"`
+	Count   int64   `json:"count"`
+}
+
+func (r *PayeeRepository) Create(payee *models.Payee) error {
+	return r.db.Create(payee).Error
+}
+
+func (r *PayeeRepository) Update(payee *models.Payee) error {
+	return r.db.Save(payee).Error
+}
+
+// Delete removes the payee and unlinks its transactions and bills, which keep the payee name as text.
+// It returns the unlinked transactions as they were before.
+func (r *PayeeRepository) Delete(id uint, userID uint) ([]models.Transaction, error) {
+	var unlinked []models.Transaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payee_id = ? AND user_id = ?", id, userID).
+			Find(&unlinked).Error
+		if err != nil {
+			return err
+		}
+		if len(unlinked) > 0 {
+			err := tx.Unscoped().Model(&models.Transaction{}).
+				Where("id IN ?", transactionIDs(unlinked)).
+				Updates(map[string]interface{}{"payee_id": nil, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Model(&models.Bill{}).Where("payee_id = ? AND user_id = ?", id, userID).Update("payee_id", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Payee{}).Error
+	})
+	return unlinked, err
+}
+
+func (r *PayeeRepository) FindByID(id uint, userID uint) (*models.Payee, error) {
+	var payee models.Payee
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&payee).Error
+	return &payee, err
+}
+
+func (r *PayeeRepository) FindAll(userID uint) ([]models.Payee, error) {
+	var payees []models.Payee
+	err := r.db.Where("user_id = ?", userID).Order("name asc").Find(&payees).Error
+	return payees, err
+}
+
+// UpdateTransactionNames copies a renamed payee's name onto its linked transactions,
+// except reconciled ones. It returns the renamed transactions as they were before.
+func (r *PayeeRepository) UpdateTransactionNames(id uint, userID uint, name string) ([]models.Transaction, error) {
+	var renamed []models.Transaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payee_id = ? AND user_id = ? AND payee <> ?", id, userID, name).
+			Where(notLocked).
+			Find(&renamed).Error
+		if err != nil || len(renamed) == 0 {
+			return err
+		}
+		return tx.Model(&models.Transaction{}).
+			Where("id IN ?", transactionIDs(renamed)).
+			Updates(map[string]interface{}{"payee": name, "version": gorm.Expr("version + 1")}).Error
+	})
+	return renamed, err
+}
+
+// FindTop totals the user's transactions of txType per payee in [startDate, endDate).
+func (r *PayeeRepository) FindTop(userID uint, txType string, startDate, endDate time.Time, limit int) ([]PayeeTotal, error) {
+	var results []PayeeTotal
+	err := r.db.Model(&models.Transaction{}).
+		Select("payees.id as payee_id, payees.name as name, sum(transactions.amount) as total, count(*) as count").
+		Joins("join payees on payees.id = transactions.payee_id").
+		Where("transactions.user_id = ? AND transactions.type = ? AND transactions.date >= ? AND transactions.date < ?",
+			userID, txType, startDate, endDate).
+		Where("transactions." + notDraft).
+		Group("payees.id, payees.name").
+		Order("total desc").
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PushRepository struct {
+	db *gorm.DB
+}
+
+func NewPushRepository(db *gorm.DB) *PushRepository {
+	return &PushRepository{db}
+}
+
+// Upsert stores the subscription, refreshing the user's existing row for the
+// same endpoint. Another user's row for the endpoint is left alone.
+func (r *PushRepository) Upsert(sub *models.PushSubscription) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "endpoint"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"p256dh":     gorm.Expr("excluded.p256dh"),
+			"auth":       gorm.Expr("excluded.auth"),
+			"device":     gorm.Expr("excluded.device"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(sub).Error
+}
+
+func (r *PushRepository) FindByUser(userID uint) ([]models.PushSubscription, error) {
+	var subs []models.PushSubscription
+	err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&subs).Error
+	return subs, err
+}
+
+func (r *PushRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PushSubscription{}).Error
+}
+
+func (r *PushRepository) DeleteByID(id uint) error {
+	return r.db.Delete(&models.PushSubscription{}, id).Error
+}
+
+func (r *PushRepository) TouchLastUsed(id uint) error {
+	return r.db.Model(&models.PushSubscription{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error
+}
+
+func (r *PushRepository) FindVapidKey() (*models.VapidKey, error) {
+	var key models.VapidKey
+	err := r.db.Order("id asc").First(&key).Error
+	return &key, err
+}
+
+func (r *PushRepository) CreateVapidKey(key *models.VapidKey) error {
+	return r.db.Create(key).Error
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type ReceiptRepository struct {
+	db *gorm.DB
+}
+
+func NewReceiptRepository(db *gorm.DB) *ReceiptRepository {
+	return &ReceiptRepository{db}
+}
+
+func (r *ReceiptRepository) Create(receipt *models.EmailReceipt) error {
+	return r.db.Create(receipt).Error
+}
+
+func (r *ReceiptRepository) FindByMessageID(userID uint, messageID string) (*models.EmailReceipt, error) {
+	var receipt models.EmailReceipt
+	err := r.db.Where("user_id = ? AND message_id = ?", userID, messageID).First(&receipt).Error
+	return &receipt, err
+}
+
+// FindAll lists the user's receipts, newest first.
+func (r *ReceiptRepository) FindAll(userID uint) ([]models.EmailReceipt, error) {
+	var receipts []models.EmailReceipt
+	err := r.db.Where("user_id = ?", userID).Order("date desc, id desc").Find(&receipts).Error
+	return receipts, err
+}
+
+// ReplaceAddress stores the user's receipt address, dropping any earlier one so
+// mail to it is no longer accepted.
+func (r *ReceiptRepository) ReplaceAddress(address *models.ReceiptAddress) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", address.UserID).Delete(&models.ReceiptAddress{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(address).Error
+	})
+}
+
+func (r *ReceiptRepository) FindAddressByUser(userID uint) (*models.ReceiptAddress, error) {
+	var address models.ReceiptAddress
+	err := r.db.Where("user_id = ?", userID).First(&address).Error
+	return &address, err
+}
+
+func (r *ReceiptRepository) FindAddressByToken(token string) (*models.ReceiptAddress, error) {
+	var address models.ReceiptAddress
+	err := r.db.Where("token = ?", token).First(&address).Error
+	return &address, err
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db}
+}
+
+func (r *ReconciliationRepository) Create(rec *models.Reconciliation) error {
+	return r.db.Create(rec).Error
+}
+
+func (r *ReconciliationRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Reconciliation{}).Error
+}
+
+func (r *ReconciliationRepository) FindByID(id uint, userID uint) (*models.Reconciliation, error) {
+	var rec models.Reconciliation
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&rec).Error
+	return &rec, err
+}
+
+// FindByAccount lists an account's reconciliations, latest statement first.
+func (r *ReconciliationRepository) FindByAccount(accountID uint, userID uint) ([]models.Reconciliation, error) {
+	var recs []models.Reconciliation
+	err := r.db.Where("account_id = ? AND user_id = ?", accountID, userID).
+		Order("statement_date desc").
+		Find(&recs).Error
+	return recs, err
+}
+
+func (r *ReconciliationRepository) FindOpen(accountID uint, userID uint) (*models.Reconciliation, error) {
+	var rec models.Reconciliation
+	err := r.db.Where("account_id = ? AND user_id = ? AND status = ?", accountID, userID, "open").First(&rec).Error
+	return &rec, err
+}
+
+// ClearedBalance nets the account's cleared and reconciled transactions dated before end.
+func (r *ReconciliationRepository) ClearedBalance(accountID uint, userID uint, end time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.Transaction{}).
+		Select(signedAmount).
+		Where("account_id = ? AND user_id = ? AND date < ? AND status IN ?", accountID, userID, end, []string{"cleared", "reconciled"}).
+		Scan(&total).Error
+	return total, err
+}
+
+// FindUnreconciled lists the account's pending and cleared transactions dated
+// before end, oldest first, as they would be ticked off a statement.
+func (r *ReconciliationRepository) FindUnreconciled(accountID uint, userID uint, end time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Preload("Category").
+		Where("account_id = ? AND user_id = ? AND date < ? AND status IN ?", accountID, userID, end, []string{"pending", "cleared"}).
+		Order("date asc, id asc").
+		Find(&transactions).Error
+	if err == nil {
+		for i := range transactions {
+			transactions[i].CategoryName = transactions[i].Category.Name
+		}
+	}
+	return transactions, err
+}
+
+// SetStatus moves the account's transactions among ids from one status to
+// another and returns them as they were before the change.
+func (r *ReconciliationRepository) SetStatus(ids []uint, accountID uint, userID uint, from string, to string) ([]models.Transaction, error) {
+	var changed []models.Transaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND account_id = ? AND user_id = ? AND status = ?", ids, accountID, userID, from).
+			Find(&changed).Error
+		if err != nil || len(changed) == 0 {
+			return err
+		}
+		return tx.Model(&models.Transaction{}).
+			Where("id IN ?", transactionIDs(changed)).
+			Updates(map[string]interface{}{"status": to, "version": gorm.Expr("version + 1")}).Error
+	})
+	return changed, err
+}
+
+// Complete locks the cleared transactions dated before end as reconciled and
+// closes the session, in one database transaction. It returns the locked
+// transactions as they were before.
+func (r *ReconciliationRepository) Complete(rec *models.Reconciliation, end time.Time) ([]models.Transaction, error) {
+	var locked []models.Transaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("account_id = ? AND user_id = ? AND date < ? AND status = ?", rec.AccountID, rec.UserID, end, "cleared").
+			Find(&locked).Error
+		if err != nil {
+			return err
+		}
+		if len(locked) > 0 {
+			err := tx.Model(&models.Transaction{}).
+				Where("id IN ?", transactionIDs(locked)).
+				Updates(map[string]interface{}{"status": "reconciled", "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		rec.Status, rec.CompletedAt = "completed", &now
+		return tx.Model(rec).Updates(map[string]interface{}{"status": rec.Status, "completed_at": now}).Error
+	})
+	return locked, err
+}
+
+func transactionIDs(transactions []models.Transaction) []uint {
+	ids := make([]uint, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+	return ids
+}
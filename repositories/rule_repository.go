@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type RuleRepository struct {
+	db *gorm.DB
+}
+
+func NewRuleRepository(db *gorm.DB) *RuleRepository {
+	return &RuleRepository{db}
+}
+
+func (r *RuleRepository) Create(rule *models.Rule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *RuleRepository) Update(rule *models.Rule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *RuleRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Rule{}).Error
+}
+
+func (r *RuleRepository) FindByID(id uint, userID uint) (*models.Rule, error) {
+	var rule models.Rule
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&rule).Error
+	return &rule, err
+}
+
+// FindAll returns the user's rules in the order they are applied.
+func (r *RuleRepository) FindAll(userID uint) ([]models.Rule, error) {
+	var rules []models.Rule
+	err := r.db.Where("user_id = ?", userID).Order("priority desc, id asc").Find(&rules).Error
+	return rules, err
+}
+
+func (r *RuleRepository) FindEnabled(userID uint) ([]models.Rule, error) {
+	var rules []models.Rule
+	err := r.db.Where("user_id = ? AND enabled = ?", userID, true).Order("priority desc, id asc").Find(&rules).Error
+	return rules, err
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SyncRepository reads and writes rows for offline clients. Every query is
+// unscoped so soft-deleted rows are visible as tombstones.
+type SyncRepository struct {
+	db *gorm.DB
+}
+
+func NewSyncRepository(db *gorm.DB) *SyncRepository {
+	return &SyncRepository{db}
+}
+
+func (r *SyncRepository) FindTransactionByUUID(userID uint, uuid string) (*models.Transaction, error) {
+	var t models.Transaction
+	err := r.db.Unscoped().Where("user_id = ? AND uuid = ?", userID, uuid).First(&t).Error
+	return &t, err
+}
+
+func (r *SyncRepository) FindCategoryByUUID(userID uint, uuid string) (*models.Category, error) {
+	var c models.Category
+	err := r.db.Unscoped().Where("(user_id = ? OR user_id IS NULL) AND uuid = ?", userID, uuid).First(&c).Error
+	return &c, err
+}
+
+// CreateTransaction inserts t unless the user already has a row with its uuid,
+// and reports whether it did.
+func (r *SyncRepository) CreateTransaction(t *models.Transaction) (bool, error) {
+	result := r.db.Clauses(onUUIDConflict).Create(t)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateCategory inserts c unless the user already has a row with its uuid,
+// and reports whether it did.
+func (r *SyncRepository) CreateCategory(c *models.Category) (bool, error) {
+	result := r.db.Clauses(onUUIDConflict).Create(c)
+	return result.RowsAffected > 0, result.Error
+}
+
+// onUUIDConflict skips an insert that lost a race with another for the same uuid.
+var onUUIDConflict = clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "uuid"}}, DoNothing: true}
+
+// Now is the database's clock, which all app instances share.
+func (r *SyncRepository) Now() (time.Time, error) {
+	var now time.Time
+	err := r.db.Raw("SELECT now()").Scan(&now).Error
+	return now, err
+}
+
+// UpdateTransactionIfVersion writes t only if the stored row is still at baseVersion
+// and not reconciled. It reports false when another write got there first.
+func (r *SyncRepository) UpdateTransactionIfVersion(t *models.Transaction, baseVersion uint, deleted bool) (bool, error) {
+	updates := map[string]interface{}{
+		"version":    baseVersion + 1,
+		"deleted_at": nil,
+	}
+	if deleted {
+		updates["deleted_at"] = time.Now()
+	} else {
+		updates["type"] = t.Type
+		updates["category_id"] = t.CategoryID
+		updates["amount"] = t.Amount
+		updates["description"] = t.Description
+		updates["date"] = t.Date
+	}
+
+	result := r.db.Unscoped().Model(&models.Transaction{}).
+		Where("id = ? AND user_id = ? AND version = ?", t.ID, t.UserID, baseVersion).
+		Where(notLocked).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateCategoryIfVersion writes c only if the stored row is still at baseVersion.
+func (r *SyncRepository) UpdateCategoryIfVersion(c *models.Category, baseVersion uint, deleted bool) (bool, error) {
+	updates := map[string]interface{}{
+		"version":    baseVersion + 1,
+		"deleted_at": nil,
+	}
+	if deleted {
+		updates["deleted_at"] = time.Now()
+	} else {
+		updates["name"] = c.Name
+	}
+
+	result := r.db.Unscoped().Model(&models.Category{}).
+		Where("id = ? AND user_id = ? AND version = ?", c.ID, c.UserID, baseVersion).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// TransactionsChangedSince returns rows written or deleted after since. A zero
+// since returns a full snapshot without tombstones.
+func (r *SyncRepository) TransactionsChangedSince(userID uint, since time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	query := r.db.Unscoped().
+		Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID)
+	if since.IsZero() {
+		query = query.Where("deleted_at IS NULL")
+	} else {
+		query = query.Where("(updated_at > ? OR deleted_at > ?)", since, since)
+	}
+	err := query.Order("updated_at asc").Find(&transactions).Error
+	return transactions, err
+}
+
+// CategoriesChangedSince returns the user's and the default categories written or deleted after since.
+func (r *SyncRepository) CategoriesChangedSince(userID uint, since time.Time) ([]models.Category, error) {
+	var categories []models.Category
+	query := r.db.Unscoped().Where("(user_id = ? OR user_id IS NULL)", userID)
+	if since.IsZero() {
+		query = query.Where("deleted_at IS NULL")
+	} else {
+		query = query.Where("(updated_at > ? OR deleted_at > ?)", since, since)
+	}
+	err := query.Order("updated_at asc").Find(&categories).Error
+	return categories, err
+}
+
+// TombstonesSince returns the user's purged rows that were deleted after since.
+// A zero since is a full snapshot, which needs none.
+func (r *SyncRepository) TombstonesSince(userID uint, since time.Time) ([]models.SyncTombstone, error) {
+	var tombstones []models.SyncTombstone
+	if since.IsZero() {
+		return tombstones, nil
+	}
+	err := r.db.Where("user_id = ? AND deleted_at > ?", userID, since).Order("deleted_at asc").Find(&tombstones).Error
+	return tombstones, err
+}
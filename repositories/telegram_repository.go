@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type TelegramRepository struct {
+	db *gorm.DB
+}
+
+func NewTelegramRepository(db *gorm.DB) *TelegramRepository {
+	return &TelegramRepository{db}
+}
+
+// CreateCode stores a new link code, replacing any the user requested before.
+func (r *TelegramRepository) CreateCode(code *models.TelegramLinkCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", code.UserID).Delete(&models.TelegramLinkCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(code).Error
+	})
+}
+
+// ConsumeCode deletes an unexpired code and returns it, so each code works once.
+func (r *TelegramRepository) ConsumeCode(code string) (*models.TelegramLinkCode, error) {
+	var linkCode models.TelegramLinkCode
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("code = ? AND expires_at > ?", code, time.Now()).First(&linkCode).Error; err != nil {
+			return err
+		}
+		return tx.Where("code = ?", code).Delete(&models.TelegramLinkCode{}).Error
+	})
+	return &linkCode, err
+}
+
+// Link connects the chat to the user, dropping any earlier link of either.
+func (r *TelegramRepository) Link(link *models.TelegramLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? OR chat_id = ?", link.UserID, link.ChatID).Delete(&models.TelegramLink{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(link).Error
+	})
+}
+
+func (r *TelegramRepository) FindByChat(chatID int64) (*models.TelegramLink, error) {
+	var link models.TelegramLink
+	err := r.db.Where("chat_id = ?", chatID).First(&link).Error
+	return &link, err
+}
+
+func (r *TelegramRepository) FindByUser(userID uint) (*models.TelegramLink, error) {
+	var link models.TelegramLink
+	err := r.db.Where("user_id = ?", userID).First(&link).Error
+	return &link, err
+}
+
+func (r *TelegramRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.TelegramLink{}).Error
+}
+
+func (r *TelegramRepository) DeleteByChat(chatID int64) error {
+	return r.db.Where("chat_id = ?", chatID).Delete(&models.TelegramLink{}).Error
+}
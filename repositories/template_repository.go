@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+type TemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(db *gorm.DB) *TemplateRepository {
+	return &TemplateRepository{db}
+}
+
+func (r *TemplateRepository) Create(template *models.TransactionTemplate) error {
+	return r.db.Create(template).Error
+}
+
+// Update writes the editable fields, leaving the usage count alone.
+func (r *TemplateRepository) Update(template *models.TransactionTemplate) error {
+	return r.db.Model(template).
+		Select("name", "type", "category_id", "amount", "description", "tags", "account_id").
+		Updates(template).Error
+}
+
+func (r *TemplateRepository) Delete(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.TransactionTemplate{}).Error
+}
+
+func (r *TemplateRepository) FindByID(id uint, userID uint) (*models.TransactionTemplate, error) {
+	var template models.TransactionTemplate
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&template).Error
+	return &template, err
+}
+
+// FindAll lists the user's templates, most used first.
+func (r *TemplateRepository) FindAll(userID uint) ([]models.TransactionTemplate, error) {
+	var templates []models.TransactionTemplate
+	err := r.db.Where("user_id = ?", userID).
+		Order("usage_count desc, last_used_at desc nulls last, name asc").
+		Find(&templates).Error
+	return templates, err
+}
+
+// RecordUse bumps the usage count atomically so concurrent taps are all counted.
+func (r *TemplateRepository) RecordUse(id uint, userID uint, at time.Time) error {
+	return r.db.Model(&models.TransactionTemplate{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{"usage_count": gorm.Expr("usage_count + 1"), "last_used_at": at}).Error
+}
@@ -7,6 +7,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// notDraft keeps drafts, which await review, out of totals and reports.
+const notDraft = "status <> 'draft'"
+
+// notLocked leaves out reconciled transactions, which only change after an explicit unlock.
+const notLocked = "status <> 'reconciled'"
+
 type TransactionRepository struct {
 	db *gorm.DB
 }
@@ -19,12 +25,73 @@ func (r *TransactionRepository) Create(t *models.Transaction) error {
 	return r.db.Create(t).Error
 }
 
-func (r *TransactionRepository) Update(t *models.Transaction) error {
-	return r.db.Save(t).Error
+// UpdateFields writes only the given columns and bumps the version. When
+// expectedVersion is non-zero the write only happens if the row is still at
+// that version. It reports whether a row was updated.
+func (r *TransactionRepository) UpdateFields(id uint, userID uint, fields map[string]interface{}, expectedVersion uint) (bool, error) {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updates["version"] = gorm.Expr("version + 1")
+
+	query := r.db.Model(&models.Transaction{}).Where("id = ? AND user_id = ?", id, userID)
+	if expectedVersion > 0 {
+		query = query.Where("version = ?", expectedVersion)
+	}
+	result := query.Updates(updates)
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *TransactionRepository) Delete(id uint, userID uint) error {
-	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Transaction{}).Error
+// Delete soft-deletes the transaction and bumps its version so sync clients receive the tombstone.
+// Reconciled transactions are left alone; it reports whether a row was deleted.
+func (r *TransactionRepository) Delete(id uint, userID uint) (bool, error) {
+	result := r.db.Model(&models.Transaction{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Where(notLocked).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Merge folds remove into keep in one database transaction: it applies fields
+// to keep, soft-deletes remove and clears every duplicate link to remove. Both
+// rows must still be at the given versions and not reconciled, or it returns
+// ErrVersionChanged and changes nothing.
+func (r *TransactionRepository) Merge(userID uint, keepID uint, keepVersion uint, removeID uint, removeVersion uint, fields map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(fields) > 0 {
+			updates := make(map[string]interface{}, len(fields)+1)
+			for k, v := range fields {
+				updates[k] = v
+			}
+			updates["version"] = gorm.Expr("version + 1")
+			result := tx.Model(&models.Transaction{}).
+				Where("id = ? AND user_id = ? AND version = ?", keepID, userID, keepVersion).
+				Where(notLocked).
+				Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrVersionChanged
+			}
+		}
+
+		result := tx.Model(&models.Transaction{}).
+			Where("id = ? AND user_id = ? AND version = ?", removeID, userID, removeVersion).
+			Where(notLocked).
+			Updates(map[string]interface{}{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionChanged
+		}
+
+		return tx.Model(&models.Transaction{}).
+			Where("user_id = ? AND duplicate_of = ?", userID, removeID).
+			Updates(map[string]interface{}{"duplicate_of": nil, "version": gorm.Expr("version + 1")}).Error
+	})
 }
 
 func (r *TransactionRepository) FindByID(id uint, userID uint) (*models.Transaction, error) {
@@ -36,6 +103,55 @@ func (r *TransactionRepository) FindByID(id uint, userID uint) (*models.Transact
 	return &t, err
 }
 
+// FindByIDs returns those of ids that belong to the user, drafts included.
+func (r *TransactionRepository) FindByIDs(userID uint, ids []uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Preload("Category").Where("user_id = ? AND id IN ?", userID, ids).Order("date desc").Find(&transactions).Error
+	for i := range transactions {
+		transactions[i].CategoryName = transactions[i].Category.Name
+	}
+	return transactions, err
+}
+
+// BulkWrite applies per-row column updates and soft deletes in one database
+// transaction, bumping each row's version. A row is only written while it is
+// still at its version in versions and not reconciled; the IDs of rows that
+// moved on are returned as skipped and left as they are.
+func (r *TransactionRepository) BulkWrite(userID uint, versions map[uint]uint, updates map[uint]map[string]interface{}, deletes []uint) ([]uint, error) {
+	var skipped []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		skipped = nil
+		write := func(id uint, changes map[string]interface{}) error {
+			changes["version"] = gorm.Expr("version + 1")
+			result := tx.Model(&models.Transaction{}).
+				Where("id = ? AND user_id = ? AND version = ?", id, userID, versions[id]).
+				Where(notLocked).
+				Updates(changes)
+			if result.Error == nil && result.RowsAffected == 0 {
+				skipped = append(skipped, id)
+			}
+			return result.Error
+		}
+		for id, fields := range updates {
+			changes := make(map[string]interface{}, len(fields)+1)
+			for k, v := range fields {
+				changes[k] = v
+			}
+			if err := write(id, changes); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		for _, id := range deletes {
+			if err := write(id, map[string]interface{}{"deleted_at": now}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return skipped, err
+}
+
 func (r *TransactionRepository) FindAll(userID uint, filter map[string]interface{}) ([]models.Transaction, error) {
 	var transactions []models.Transaction
 	query := r.db.Preload("Category").Where("user_id = ?", userID)
@@ -46,9 +162,23 @@ func (r *TransactionRepository) FindAll(userID uint, filter map[string]interface
 	if endDate, ok := filter["end_date"].(time.Time); ok {
 		query = query.Where("date <= ?", endDate)
 	}
+	if txType, ok := filter["type"].(string); ok && txType != "" {
+		query = query.Where("type = ?", txType)
+	}
 	if categoryID, ok := filter["category_id"].(uint); ok && categoryID > 0 {
 		query = query.Where("category_id = ?", categoryID)
 	}
+	if accountID, ok := filter["account_id"].(uint); ok && accountID > 0 {
+		query = query.Where("account_id = ?", accountID)
+	}
+	// Drafts are listed only when asked for; "all" includes them
+	if status, ok := filter["status"].(string); ok && status != "" {
+		if status != "all" {
+			query = query.Where("status = ?", status)
+		}
+	} else {
+		query = query.Where(notDraft)
+	}
 
 	err := query.Order("date desc").Find(&transactions).Error
 	if err == nil {
@@ -67,7 +197,8 @@ func (r *TransactionRepository) GetSummary(userID uint, month int, year int) (ma
 
 	query := r.db.Model(&models.Transaction{}).
 		Select("type, sum(amount) as total").
-		Where("user_id = ?", userID)
+		Where("user_id = ?", userID).
+		Where(notDraft)
 
 	if month > 0 && year > 0 {
 		loc, _ := time.LoadLocation("Asia/Jakarta")
@@ -107,6 +238,7 @@ func (r *TransactionRepository) GetTimeSeriesData(userID uint, month int, year i
 	err := r.db.Model(&models.Transaction{}).
 		Select("DATE(date AT TIME ZONE 'Asia/Jakarta') as date, type, sum(amount) as total").
 		Where("user_id = ? AND date >= ? AND date < ?", userID, startDate, endDate).
+		Where(notDraft).
 		Group("1, type").
 		Order("1 asc").
 		Scan(&results).Error
@@ -160,16 +292,19 @@ func (r *TransactionRepository) GetTimeSeriesData(userID uint, month int, year i
 	return timeSeries, nil
 }
 
-func (r *TransactionRepository) GetCategoryBreakdown(userID uint, month int, year int) ([]map[string]interface{}, error) {
+// GetCategoryBreakdown totals transactions of txType (income or expense) per category.
+func (r *TransactionRepository) GetCategoryBreakdown(userID uint, month int, year int, txType string) ([]map[string]interface{}, error) {
 	var results []struct {
+		CategoryID   uint    `json:"category_id"`
 		CategoryName string  `json:"category_name"`
 		Total        float64 `json:"total"`
 	}
 
 	query := r.db.Model(&models.Transaction{}).
-		Select("categories.name as category_name, sum(amount) as total").
+		Select("categories.id as category_id, categories.name as category_name, sum(amount) as total").
 		Joins("left join categories on categories.id = transactions.category_id").
-		Where("transactions.user_id = ? AND transactions.type = 'expense'", userID)
+		Where("transactions.user_id = ? AND transactions.type = ?", userID, txType).
+		Where("transactions." + notDraft)
 
 	if month > 0 && year > 0 {
 		loc, _ := time.LoadLocation("Asia/Jakarta")
@@ -178,7 +313,7 @@ func (r *TransactionRepository) GetCategoryBreakdown(userID uint, month int, yea
 		query = query.Where("transactions.date >= ? AND transactions.date < ?", startDate, endDate)
 	}
 
-	err := query.Group("categories.name").Order("total desc").Scan(&results).Error
+	err := query.Group("categories.id, categories.name").Order("total desc").Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +325,7 @@ func (r *TransactionRepository) GetCategoryBreakdown(userID uint, month int, yea
 			name = "Uncategorized"
 		}
 		breakdown = append(breakdown, map[string]interface{}{
+			"category_id":   res.CategoryID,
 			"category_name": name,
 			"total":         res.Total,
 		})
@@ -197,3 +333,31 @@ func (r *TransactionRepository) GetCategoryBreakdown(userID uint, month int, yea
 
 	return breakdown, nil
 }
+
+// GetMonthlyTotals sums transactions of the given type per category and month, for all months before endDate.
+func (r *TransactionRepository) GetMonthlyTotals(userID uint, txType string, endDate time.Time) ([]MonthlyTotal, error) {
+	var results []MonthlyTotal
+	err := r.db.Model(&models.Transaction{}).
+		Select("CAST(EXTRACT(YEAR FROM date AT TIME ZONE 'Asia/Jakarta') AS INTEGER) as year, "+
+			"CAST(EXTRACT(MONTH FROM date AT TIME ZONE 'Asia/Jakarta') AS INTEGER) as month, "+
+			"category_id, sum(amount) as total").
+		Where("user_id = ? AND type = ? AND date < ?", userID, txType, endDate).
+		Where(notDraft).
+		Group("1, 2, category_id").
+		Scan(&results).Error
+	return results, err
+}
+
+// SumBetween totals transactions of a type in [startDate, endDate), optionally limited to one category.
+func (r *TransactionRepository) SumBetween(userID uint, txType string, categoryID uint, startDate, endDate time.Time) (float64, error) {
+	var total float64
+	query := r.db.Model(&models.Transaction{}).
+		Select("COALESCE(sum(amount), 0)").
+		Where("user_id = ? AND type = ? AND date >= ? AND date < ?", userID, txType, startDate, endDate).
+		Where(notDraft)
+	if categoryID > 0 {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	err := query.Scan(&total).Error
+	return total, err
+}
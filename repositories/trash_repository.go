@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"gorm.io/gorm"
+)
+
+// TrashRepository works on soft-deleted rows only.
+type TrashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *TrashRepository {
+	return &TrashRepository{db}
+}
+
+func (r *TrashRepository) FindTransactions(userID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Unscoped().
+		Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Find(&transactions).Error
+	if err == nil {
+		for i := range transactions {
+			transactions[i].CategoryName = transactions[i].Category.Name
+		}
+	}
+	return transactions, err
+}
+
+func (r *TrashRepository) FindCategories(userID uint) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Find(&categories).Error
+	return categories, err
+}
+
+func (r *TrashRepository) FindTransaction(id uint, userID uint) (*models.Transaction, error) {
+	var t models.Transaction
+	err := r.db.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&t).Error
+	return &t, err
+}
+
+func (r *TrashRepository) FindCategory(id uint, userID uint) (*models.Category, error) {
+	var c models.Category
+	err := r.db.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&c).Error
+	return &c, err
+}
+
+// FindCategoryByID returns the category whether or not it is deleted.
+func (r *TrashRepository) FindCategoryByID(id uint) (*models.Category, error) {
+	var c models.Category
+	err := r.db.Unscoped().First(&c, id).Error
+	return &c, err
+}
+
+func (r *TrashRepository) CountActiveCategoriesByName(userID uint, name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).
+		Where("(user_id = ? OR user_id IS NULL) AND LOWER(name) = LOWER(?)", userID, name).
+		Count(&count).Error
+	return count, err
+}
+
+// CountTransactionsByCategory counts every transaction referencing the category, including trashed ones.
+func (r *TrashRepository) CountTransactionsByCategory(categoryID uint) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Transaction{}).Where("category_id = ?", categoryID).Count(&count).Error
+	return count, err
+}
+
+// RestoreTransaction undeletes the transaction, optionally pointing it at a different category.
+func (r *TrashRepository) RestoreTransaction(id uint, userID uint, categoryID uint) error {
+	return r.db.Unscoped().Model(&models.Transaction{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		Updates(map[string]interface{}{
+			"deleted_at":  nil,
+			"category_id": categoryID,
+			"version":     gorm.Expr("version + 1"),
+		}).Error
+}
+
+func (r *TrashRepository) RestoreCategory(id uint) error {
+	return r.db.Unscoped().Model(&models.Category{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+}
+
+// keepTombstones records sync tombstones for the trashed rows of table matching
+// where, before they are purged.
+func keepTombstones(tx *gorm.DB, table string, entity string, where string, args ...interface{}) error {
+	return tx.Exec("INSERT INTO sync_tombstones (user_id, entity, uuid, deleted_at) "+
+		"SELECT user_id, ?, uuid, deleted_at FROM "+table+" WHERE deleted_at IS NOT NULL AND uuid <> '' AND ("+where+")",
+		append([]interface{}{entity}, args...)...).Error
+}
+
+func (r *TrashRepository) PurgeTransaction(id uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := keepTombstones(tx, "transactions", "transaction", "id = ? AND user_id = ?", id, userID); err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+			Delete(&models.Transaction{}).Error
+	})
+}
+
+// PurgeCategory permanently removes a trashed category along with the budgets assigned to it.
+func (r *TrashRepository) PurgeCategory(id uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("category_id = ? AND user_id = ?", id, userID).Delete(&models.Budget{}).Error; err != nil {
+			return err
+		}
+		if err := keepTombstones(tx, "categories", "category", "id = ? AND user_id = ?", id, userID); err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+			Delete(&models.Category{}).Error
+	})
+}
+
+// PurgeDeletedBefore permanently removes transactions trashed before cutoff, then any
+// trashed categories from the same period that nothing references any more. Every
+// purge leaves sync tombstones behind.
+func (r *TrashRepository) PurgeDeletedBefore(cutoff time.Time) (int64, int64, error) {
+	var transactions, categories int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := keepTombstones(tx, "transactions", "transaction", "deleted_at < ?", cutoff); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.Transaction{})
+		if result.Error != nil {
+			return result.Error
+		}
+		transactions = result.RowsAffected
+
+		unreferenced := "deleted_at IS NOT NULL AND deleted_at < ? AND user_id IS NOT NULL " +
+			"AND NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.category_id = categories.id)"
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.Category{}).Where(unreferenced, cutoff).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Unscoped().Where("category_id IN ?", ids).Delete(&models.Budget{}).Error; err != nil {
+			return err
+		}
+		if err := keepTombstones(tx, "categories", "category", "id IN ?", ids); err != nil {
+			return err
+		}
+		result = tx.Unscoped().Where("id IN ?", ids).Delete(&models.Category{})
+		categories = result.RowsAffected
+		return result.Error
+	})
+	return transactions, categories, err
+}
@@ -28,3 +28,7 @@ func (r *UserRepository) FindByID(id uint) (*models.User, error) {
 	err := r.db.First(&user, id).Error
 	return &user, err
 }
+
+func (r *UserRepository) UpdateBudgetMode(id uint, mode string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("budget_mode", mode).Error
+}
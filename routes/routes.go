@@ -11,6 +11,23 @@ func SetupRoutes(
 	authCtrl *controllers.AuthController,
 	catCtrl *controllers.CategoryController,
 	transCtrl *controllers.TransactionController,
+	budgetCtrl *controllers.BudgetController,
+	alertCtrl *controllers.AlertController,
+	pushCtrl *controllers.PushController,
+	syncCtrl *controllers.SyncController,
+	trashCtrl *controllers.TrashController,
+	auditCtrl *controllers.AuditController,
+	ruleCtrl *controllers.RuleController,
+	payeeCtrl *controllers.PayeeController,
+	telegramCtrl *controllers.TelegramController,
+	receiptCtrl *controllers.ReceiptController,
+	accountCtrl *controllers.AccountController,
+	reconCtrl *controllers.ReconciliationController,
+	duplicateCtrl *controllers.DuplicateController,
+	templateCtrl *controllers.TemplateController,
+	billCtrl *controllers.BillController,
+	subscriptionCtrl *controllers.SubscriptionController,
+	calendarCtrl *controllers.CalendarController,
 ) {
 	api := r.Group("/api")
 	{
@@ -21,6 +38,17 @@ func SetupRoutes(
 			auth.POST("/login", authCtrl.Login)
 		}
 
+		// Telegram pushes bot updates here in webhook mode; it authenticates with the webhook secret
+		if telegramCtrl.WebhookEnabled() {
+			api.POST("/telegram/webhook", telegramCtrl.Webhook)
+		}
+
+		// Inbound-mail providers post raw receipts here, authenticated by a shared secret
+		api.POST("/email/inbound", receiptCtrl.Inbound)
+
+		// Calendar apps fetch the iCalendar feed here; the secret token in the URL authenticates
+		api.GET("/ical/:token", calendarCtrl.Feed)
+
 		// Protected Routes
 		protected := api.Group("")
 		protected.Use(middleware.AuthMiddleware())
@@ -29,8 +57,15 @@ func SetupRoutes(
 			categories := protected.Group("/categories")
 			{
 				categories.GET("", catCtrl.GetAll)
+				categories.GET("/tree", catCtrl.GetTree)
 				categories.POST("", catCtrl.Create)
+				categories.PUT("/:id", catCtrl.Update)
 				categories.DELETE("/:id", catCtrl.Delete)
+				categories.POST("/:id/merge", catCtrl.Merge)
+				categories.POST("/:id/archive", catCtrl.Archive)
+				categories.POST("/:id/unarchive", catCtrl.Unarchive)
+				categories.POST("/:id/hide", catCtrl.Hide)
+				categories.POST("/:id/unhide", catCtrl.Unhide)
 			}
 
 			// Transaction Routes
@@ -38,10 +73,173 @@ func SetupRoutes(
 			{
 				transactions.GET("", transCtrl.GetAll)
 				transactions.POST("", transCtrl.Create)
+				transactions.POST("/quick", transCtrl.QuickAdd)
+				transactions.POST("/bulk", transCtrl.Bulk)
+				transactions.GET("/suggest-category", transCtrl.SuggestCategory)
+				transactions.GET("/duplicates", duplicateCtrl.GetAll)
+				transactions.POST("/duplicates/merge", duplicateCtrl.Merge)
+				transactions.POST("/duplicates/dismiss", duplicateCtrl.Dismiss)
+				transactions.GET("/:id", transCtrl.GetByID)
 				transactions.PUT("/:id", transCtrl.Update)
+				transactions.PATCH("/:id", transCtrl.Patch)
+				transactions.GET("/:id/history", auditCtrl.GetTransactionHistory)
+				transactions.POST("/:id/unlock", transCtrl.Unlock)
 				transactions.DELETE("/:id", transCtrl.Delete)
 			}
 			protected.GET("/dashboard", transCtrl.GetDashboard)
+
+			// Transaction Template Routes
+			templates := protected.Group("/templates")
+			{
+				templates.GET("", templateCtrl.GetAll)
+				templates.POST("", templateCtrl.Create)
+				templates.PUT("/:id", templateCtrl.Update)
+				templates.DELETE("/:id", templateCtrl.Delete)
+				templates.POST("/:id/use", templateCtrl.Use)
+			}
+
+			// Draft Inbox Routes
+			inbox := protected.Group("/inbox")
+			{
+				inbox.GET("", transCtrl.GetInbox)
+				inbox.POST("/approve", transCtrl.ApproveDrafts)
+				inbox.POST("/reject", transCtrl.RejectDrafts)
+				inbox.POST("/:id/approve", transCtrl.ApproveDraft)
+			}
+
+			// Account Routes
+			accounts := protected.Group("/accounts")
+			{
+				accounts.GET("", accountCtrl.GetAll)
+				accounts.POST("", accountCtrl.Create)
+				accounts.PUT("/:id", accountCtrl.Update)
+				accounts.DELETE("/:id", accountCtrl.Delete)
+				accounts.GET("/:id/reconciliations", reconCtrl.GetByAccount)
+				accounts.POST("/:id/reconciliations", reconCtrl.Start)
+			}
+
+			// Statement Reconciliation Routes
+			reconciliations := protected.Group("/reconciliations")
+			{
+				reconciliations.GET("/:id", reconCtrl.GetByID)
+				reconciliations.POST("/:id/clear", reconCtrl.Clear)
+				reconciliations.POST("/:id/unclear", reconCtrl.Unclear)
+				reconciliations.POST("/:id/complete", reconCtrl.Complete)
+				reconciliations.DELETE("/:id", reconCtrl.Cancel)
+			}
+
+			// Budget Routes
+			budgets := protected.Group("/budgets")
+			{
+				budgets.GET("", budgetCtrl.GetMonth)
+				budgets.PUT("", budgetCtrl.Assign)
+				budgets.PUT("/mode", budgetCtrl.SetMode)
+				budgets.DELETE("/:id", budgetCtrl.Delete)
+			}
+
+			// Bill Routes
+			bills := protected.Group("/bills")
+			{
+				bills.GET("", billCtrl.GetAll)
+				bills.GET("/upcoming", billCtrl.GetUpcoming)
+				bills.POST("", billCtrl.Create)
+				bills.PUT("/:id", billCtrl.Update)
+				bills.DELETE("/:id", billCtrl.Delete)
+				bills.GET("/:id/periods", billCtrl.GetPeriods)
+			}
+
+			// Insight Routes
+			insights := protected.Group("/insights")
+			{
+				insights.GET("/subscriptions", subscriptionCtrl.GetAll)
+				insights.POST("/subscriptions/confirm", subscriptionCtrl.Confirm)
+			}
+
+			// Calendar Feed Routes
+			calendar := protected.Group("/calendar")
+			{
+				calendar.GET("", calendarCtrl.Get)
+				calendar.POST("/token", calendarCtrl.Rotate)
+				calendar.DELETE("/token", calendarCtrl.Disable)
+			}
+
+			// Alert Rule Routes
+			alerts := protected.Group("/alerts")
+			{
+				alerts.GET("", alertCtrl.GetRules)
+				alerts.POST("", alertCtrl.CreateRule)
+				alerts.PUT("/:id", alertCtrl.UpdateRule)
+				alerts.DELETE("/:id", alertCtrl.DeleteRule)
+			}
+
+			// Categorization Rule Routes
+			rules := protected.Group("/rules")
+			{
+				rules.GET("", ruleCtrl.GetRules)
+				rules.POST("", ruleCtrl.CreateRule)
+				rules.POST("/test", ruleCtrl.TestRule)
+				rules.POST("/apply", ruleCtrl.ApplyRules)
+				rules.PUT("/:id", ruleCtrl.UpdateRule)
+				rules.DELETE("/:id", ruleCtrl.DeleteRule)
+			}
+
+			// Payee Routes
+			payees := protected.Group("/payees")
+			{
+				payees.GET("", payeeCtrl.GetAll)
+				payees.GET("/top", payeeCtrl.GetTop)
+				payees.POST("", payeeCtrl.Create)
+				payees.PUT("/:id", payeeCtrl.Update)
+				payees.DELETE("/:id", payeeCtrl.Delete)
+			}
+
+			// Notification Inbox Routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", alertCtrl.GetNotifications)
+				notifications.PUT("/read-all", alertCtrl.MarkAllRead)
+				notifications.PUT("/:id/read", alertCtrl.MarkRead)
+				notifications.PUT("/:id/unread", alertCtrl.MarkUnread)
+			}
+
+			// Web Push Routes
+			push := protected.Group("/push")
+			{
+				push.GET("/vapid-public-key", pushCtrl.GetPublicKey)
+				push.GET("/subscriptions", pushCtrl.GetSubscriptions)
+				push.POST("/subscriptions", pushCtrl.Subscribe)
+				push.DELETE("/subscriptions/:id", pushCtrl.Unsubscribe)
+				push.POST("/test", pushCtrl.SendTest)
+			}
+
+			// Email Receipts; their drafts are reviewed in the inbox
+			protected.GET("/receipts", receiptCtrl.GetAll)
+			protected.GET("/receipts/address", receiptCtrl.GetAddress)
+			protected.POST("/receipts/address", receiptCtrl.RotateAddress)
+
+			// Telegram Bot Routes
+			tg := protected.Group("/telegram")
+			{
+				tg.GET("/link", telegramCtrl.GetLink)
+				tg.POST("/link", telegramCtrl.CreateLinkCode)
+				tg.DELETE("/link", telegramCtrl.Unlink)
+			}
+
+			// Offline Sync
+			protected.POST("/sync", syncCtrl.Sync)
+
+			// Trash Routes
+			trash := protected.Group("/trash")
+			{
+				trash.GET("", trashCtrl.GetAll)
+				trash.POST("/transactions/:id/restore", trashCtrl.RestoreTransaction)
+				trash.DELETE("/transactions/:id", trashCtrl.PurgeTransaction)
+				trash.POST("/categories/:id/restore", trashCtrl.RestoreCategory)
+				trash.DELETE("/categories/:id", trashCtrl.PurgeCategory)
+			}
+
+			// Audit Log
+			protected.GET("/audit", auditCtrl.GetAll)
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	AccountBank       = "bank"
+	AccountCreditCard = "credit_card"
+	AccountEwallet    = "ewallet"
+	AccountCash       = "cash"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountInUse    = errors.New("account still has transactions; move or delete them first")
+)
+
+type AccountService struct {
+	repo *repositories.AccountRepository
+}
+
+func NewAccountService(repo *repositories.AccountRepository) *AccountService {
+	return &AccountService{repo}
+}
+
+// GetAll lists the user's accounts with their current balances.
+func (s *AccountService) GetAll(userID uint) ([]models.Account, error) {
+	accounts, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.repo.Balances(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		accounts[i].Balance = accounts[i].OpeningBalance + balances[accounts[i].ID]
+	}
+	return accounts, nil
+}
+
+func (s *AccountService) GetByID(id uint, userID uint) (*models.Account, error) {
+	account, err := s.repo.FindByID(id, userID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *AccountService) Create(account *models.Account) error {
+	if err := validateAccount(account); err != nil {
+		return err
+	}
+	return s.repo.Create(account)
+}
+
+func (s *AccountService) Update(account *models.Account) error {
+	existing, err := s.GetByID(account.ID, account.UserID)
+	if err != nil {
+		return err
+	}
+	if err := validateAccount(account); err != nil {
+		return err
+	}
+	account.CreatedAt = existing.CreatedAt
+	return s.repo.Update(account)
+}
+
+// Delete removes an account that no transaction points at.
+func (s *AccountService) Delete(id uint, userID uint) error {
+	if _, err := s.GetByID(id, userID); err != nil {
+		return err
+	}
+	count, err := s.repo.CountTransactions(id, userID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAccountInUse
+	}
+	return s.repo.Delete(id, userID)
+}
+
+func validateAccount(account *models.Account) error {
+	account.Name = strings.TrimSpace(account.Name)
+	if account.Name == "" {
+		return errors.New("name is required")
+	}
+	switch account.Type {
+	case "":
+		account.Type = AccountBank
+	case AccountBank, AccountCreditCard, AccountEwallet, AccountCash:
+	default:
+		return errors.New("type must be bank, credit_card, ewallet or cash")
+	}
+	return nil
+}
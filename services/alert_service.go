@@ -0,0 +1,336 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	AlertBudget           = "budget"
+	AlertLargeTransaction = "large_transaction"
+	AlertDailySpend       = "daily_spend"
+	AlertLowBalance       = "low_balance"
+)
+
+var (
+	ErrChannelUnavailable = errors.New("notification channel is not available")
+	ErrWebhookRequired    = errors.New("webhook_url is required for the webhook channel")
+)
+
+type AlertService struct {
+	repo       *repositories.AlertRepository
+	notifRepo  *repositories.NotificationRepository
+	transRepo  *repositories.TransactionRepository
+	budgetRepo *repositories.BudgetRepository
+	userRepo   *repositories.UserRepository
+	notifiers  map[string]Notifier
+}
+
+func NewAlertService(
+	repo *repositories.AlertRepository,
+	notifRepo *repositories.NotificationRepository,
+	transRepo *repositories.TransactionRepository,
+	budgetRepo *repositories.BudgetRepository,
+	userRepo *repositories.UserRepository,
+	notifiers ...Notifier,
+) *AlertService {
+	s := &AlertService{repo, notifRepo, transRepo, budgetRepo, userRepo, make(map[string]Notifier)}
+	for _, n := range notifiers {
+		s.notifiers[n.Channel()] = n
+	}
+	return s
+}
+
+func (s *AlertService) validateRule(rule *models.AlertRule) error {
+	switch rule.Type {
+	case AlertBudget, AlertLargeTransaction, AlertDailySpend, AlertLowBalance:
+	default:
+		return errors.New("unknown alert type")
+	}
+	if rule.Type != AlertLowBalance && rule.Threshold <= 0 {
+		return errors.New("threshold must be greater than zero")
+	}
+	return s.validateChannels(rule.Channels, rule.WebhookURL)
+}
+
+// validateChannels checks that every channel in the comma-separated list is
+// configured, and that a webhook has an https URL to post to.
+func (s *AlertService) validateChannels(channels string, webhookURL string) error {
+	for _, channel := range splitChannels(channels) {
+		if _, ok := s.notifiers[channel]; !ok {
+			return ErrChannelUnavailable
+		}
+		if channel == "webhook" && webhookURL == "" {
+			return ErrWebhookRequired
+		}
+	}
+	if webhookURL != "" {
+		return validateWebhookURL(webhookURL)
+	}
+	return nil
+}
+
+func (s *AlertService) CreateRule(rule *models.AlertRule) error {
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+	return s.repo.Create(rule)
+}
+
+func (s *AlertService) UpdateRule(rule *models.AlertRule) error {
+	existing, err := s.repo.FindByID(rule.ID, rule.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+	rule.CreatedAt = existing.CreatedAt
+	return s.repo.Update(rule)
+}
+
+func (s *AlertService) DeleteRule(id uint, userID uint) error {
+	return s.repo.Delete(id, userID)
+}
+
+func (s *AlertService) GetRules(userID uint) ([]models.AlertRule, error) {
+	return s.repo.FindAll(userID)
+}
+
+func (s *AlertService) GetNotifications(userID uint, unreadOnly bool) ([]models.Notification, int64, error) {
+	notifications, err := s.notifRepo.FindAll(userID, unreadOnly)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.notifRepo.CountUnread(userID)
+	return notifications, unread, err
+}
+
+func (s *AlertService) MarkRead(id uint, userID uint) error {
+	return s.notifRepo.MarkRead(id, userID)
+}
+
+func (s *AlertService) MarkUnread(id uint, userID uint) error {
+	return s.notifRepo.MarkUnread(id, userID)
+}
+
+func (s *AlertService) MarkAllRead(userID uint) error {
+	return s.notifRepo.MarkAllRead(userID)
+}
+
+// Notify stores a notification in the inbox and, if it is new, delivers it
+// over the given external channels.
+func (s *AlertService) Notify(user *models.User, rule *models.AlertRule, n *models.Notification, channels []string) error {
+	created, err := s.notifRepo.CreateOnce(n)
+	if err != nil || !created {
+		return err
+	}
+
+	for _, channel := range channels {
+		notifier, ok := s.notifiers[channel]
+		if !ok {
+			continue
+		}
+		go func(notifier Notifier) {
+			if err := notifier.Notify(user, rule, n); err != nil {
+				log.Printf("Warning: Failed to deliver %s notification %d: %v", notifier.Channel(), n.ID, err)
+			}
+		}(notifier)
+	}
+	return nil
+}
+
+// NotifyUser stores a notification that no alert rule produced, such as a bill
+// reminder, and delivers it over the given channels.
+func (s *AlertService) NotifyUser(userID uint, n *models.Notification, channels string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.Notify(user, &models.AlertRule{UserID: userID}, n, splitChannels(channels))
+}
+
+// EvaluateTransaction checks the user's rules after a transaction is created or updated.
+// Failures are logged rather than returned so alerts never block writes.
+func (s *AlertService) EvaluateTransaction(t *models.Transaction) {
+	if t.Type != "expense" {
+		return
+	}
+	if err := s.evaluate(t.UserID, t, time.Now()); err != nil {
+		log.Printf("Warning: Failed to evaluate alerts for transaction %d: %v", t.ID, err)
+	}
+}
+
+// EvaluateScheduled checks every user's rules against the current state of their ledger.
+func (s *AlertService) EvaluateScheduled() {
+	userIDs, err := s.repo.FindUserIDsWithRules()
+	if err != nil {
+		log.Printf("Warning: Failed to load alert rules: %v", err)
+		return
+	}
+	for _, userID := range userIDs {
+		if err := s.evaluate(userID, nil, time.Now()); err != nil {
+			log.Printf("Warning: Failed to evaluate alerts for user %d: %v", userID, err)
+		}
+	}
+}
+
+func (s *AlertService) evaluate(userID uint, t *models.Transaction, now time.Time) error {
+	rules, err := s.repo.FindEnabled(userID)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	ref := now.In(loc)
+	if t != nil {
+		ref = t.Date.In(loc)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		notifications, err := s.check(rule, t, ref)
+		if err != nil {
+			return err
+		}
+		for _, n := range notifications {
+			if err := s.Notify(user, rule, n, splitChannels(rule.Channels)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *AlertService) check(rule *models.AlertRule, t *models.Transaction, ref time.Time) ([]*models.Notification, error) {
+	newNotification := func(key, title, body string) *models.Notification {
+		return &models.Notification{
+			UserID:   rule.UserID,
+			RuleID:   &rule.ID,
+			Type:     rule.Type,
+			Title:    title,
+			Body:     body,
+			DedupKey: fmt.Sprintf("rule:%d:%s", rule.ID, key),
+		}
+	}
+
+	switch rule.Type {
+	case AlertLargeTransaction:
+		if t == nil || t.Amount < rule.Threshold {
+			return nil, nil
+		}
+		return []*models.Notification{newNotification(
+			fmt.Sprintf("tx:%d", t.ID),
+			"Large transaction recorded",
+			fmt.Sprintf("An expense of %.2f (%s) exceeds your limit of %.2f.", t.Amount, t.Description, rule.Threshold),
+		)}, nil
+
+	case AlertDailySpend:
+		dayStart := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+		spent, err := s.transRepo.SumBetween(rule.UserID, "expense", 0, dayStart, dayStart.AddDate(0, 0, 1))
+		if err != nil || spent < rule.Threshold {
+			return nil, err
+		}
+		return []*models.Notification{newNotification(
+			"day:"+dayStart.Format("2006-01-02"),
+			"Daily spending limit reached",
+			fmt.Sprintf("You spent %.2f on %s, above your daily limit of %.2f.", spent, dayStart.Format("2006-01-02"), rule.Threshold),
+		)}, nil
+
+	case AlertLowBalance:
+		summary, err := s.transRepo.GetSummary(rule.UserID, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		balance := summary["income"] - summary["expense"]
+		if balance > rule.Threshold {
+			return nil, nil
+		}
+		return []*models.Notification{newNotification(
+			"balance:"+ref.Format("2006-01-02"),
+			"Low balance",
+			fmt.Sprintf("Your balance is %.2f, at or below your alert level of %.2f.", balance, rule.Threshold),
+		)}, nil
+
+	case AlertBudget:
+		return s.checkBudgets(rule, t, ref, newNotification)
+	}
+	return nil, nil
+}
+
+func (s *AlertService) checkBudgets(
+	rule *models.AlertRule,
+	t *models.Transaction,
+	ref time.Time,
+	newNotification func(key, title, body string) *models.Notification,
+) ([]*models.Notification, error) {
+	year, month := ref.Year(), int(ref.Month())
+
+	var budgets []models.Budget
+	switch {
+	case t != nil:
+		if rule.CategoryID != nil && *rule.CategoryID != t.CategoryID {
+			return nil, nil
+		}
+		budget, err := s.budgetRepo.FindByCategory(rule.UserID, t.CategoryID, year, month)
+		if err != nil {
+			return nil, nil
+		}
+		budgets = append(budgets, *budget)
+	case rule.CategoryID != nil:
+		budget, err := s.budgetRepo.FindByCategory(rule.UserID, *rule.CategoryID, year, month)
+		if err != nil {
+			return nil, nil
+		}
+		budgets = append(budgets, *budget)
+	default:
+		var err error
+		budgets, err = s.budgetRepo.FindByPeriod(rule.UserID, year, month)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	monthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, ref.Location())
+	var notifications []*models.Notification
+	for _, b := range budgets {
+		if b.Amount <= 0 {
+			continue
+		}
+		spent, err := s.transRepo.SumBetween(rule.UserID, "expense", b.CategoryID, monthStart, monthStart.AddDate(0, 1, 0))
+		if err != nil {
+			return nil, err
+		}
+		used := spent / b.Amount * 100
+		if used < rule.Threshold {
+			continue
+		}
+		notifications = append(notifications, newNotification(
+			fmt.Sprintf("budget:%d:%04d-%02d", b.CategoryID, year, month),
+			fmt.Sprintf("Budget %.0f%% used", rule.Threshold),
+			fmt.Sprintf("You have spent %.2f of your %.2f budget (%.0f%%) for %04d-%02d.", spent, b.Amount, used, year, month),
+		))
+	}
+	return notifications, nil
+}
+
+func splitChannels(channels string) []string {
+	var result []string
+	for _, c := range strings.Split(channels, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			result = append(result, c)
+		}
+	}
+	return result
+}
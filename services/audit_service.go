@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// Actor identifies who made a change and from where.
+type Actor struct {
+	UserID uint
+	IP     string
+}
+
+type AuditService struct {
+	repo *repositories.AuditRepository
+}
+
+func NewAuditService(repo *repositories.AuditRepository) *AuditService {
+	return &AuditService{repo}
+}
+
+// auditIgnoredFields are bookkeeping or derived fields that would make every diff noisy.
+var auditIgnoredFields = map[string]bool{
+	"updated_at":    true,
+	"version":       true,
+	"category":      true,
+	"category_name": true,
+}
+
+// Record appends an entry for a change to an entity owned by ownerID. before and
+// after are snapshots of the entity (nil for creates and deletes respectively).
+// Failures are logged rather than returned so auditing never blocks a write.
+func (s *AuditService) Record(actor Actor, ownerID uint, action, entityType string, entityID uint, before, after interface{}) {
+	beforeJSON, beforeMap := auditSnapshot(before)
+	afterJSON, afterMap := auditSnapshot(after)
+
+	changes, _ := json.Marshal(diffSnapshots(beforeMap, afterMap))
+
+	entry := &models.AuditLog{
+		UserID:     ownerID,
+		ActorID:    actor.UserID,
+		IP:         actor.IP,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		Changes:    changes,
+	}
+	if err := s.repo.Create(entry); err != nil {
+		log.Printf("Warning: Failed to write audit log for %s %d: %v", entityType, entityID, err)
+	}
+}
+
+// RecordTransactionUpdates audits each transaction a bulk write changed, given as it
+// was before; change applies the write to a copy to give the after snapshot.
+func (s *AuditService) RecordTransactionUpdates(actor Actor, before []models.Transaction, change func(t *models.Transaction)) {
+	for i := range before {
+		after := before[i]
+		change(&after)
+		after.Version++
+		s.Record(actor, after.UserID, AuditUpdate, "transaction", after.ID, &before[i], &after)
+	}
+}
+
+func (s *AuditService) GetHistory(userID uint, entityType string, entityID uint) ([]models.AuditLog, error) {
+	return s.repo.FindByEntity(userID, entityType, entityID)
+}
+
+func (s *AuditService) GetAll(userID uint, filter map[string]interface{}) ([]models.AuditLog, error) {
+	return s.repo.FindAll(userID, filter)
+}
+
+func auditSnapshot(v interface{}) (json.RawMessage, map[string]interface{}) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return raw, nil
+	}
+	for k := range auditIgnoredFields {
+		delete(fields, k)
+	}
+	raw, _ = json.Marshal(fields)
+	return raw, fields
+}
+
+// diffSnapshots returns {"field": {"from": old, "to": new}} for every field whose value differs.
+func diffSnapshots(before, after map[string]interface{}) map[string]map[string]interface{} {
+	changes := make(map[string]map[string]interface{})
+	for k, old := range before {
+		if updated, ok := after[k]; !ok || !reflect.DeepEqual(old, updated) {
+			changes[k] = map[string]interface{}{"from": old, "to": after[k]}
+		}
+	}
+	for k, updated := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = map[string]interface{}{"from": nil, "to": updated}
+		}
+	}
+	return changes
+}
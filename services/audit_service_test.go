@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestAuditDiffSnapshots(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := &models.Transaction{ID: 7, Type: "expense", Amount: 25000, Description: "kopi", Date: date, Version: 1}
+	after := &models.Transaction{ID: 7, Type: "expense", Amount: 30000, Description: "kopi", Date: date, Version: 2}
+
+	_, beforeMap := auditSnapshot(before)
+	_, afterMap := auditSnapshot(after)
+	changes := diffSnapshots(beforeMap, afterMap)
+
+	if len(changes) != 1 {
+		t.Fatalf("Expected only amount to change, got %v", changes)
+	}
+	if changes["amount"]["from"] != 25000.0 || changes["amount"]["to"] != 30000.0 {
+		t.Errorf("Unexpected amount change: %v", changes["amount"])
+	}
+
+	var missing *models.Transaction
+	if raw, fields := auditSnapshot(missing); raw != nil || fields != nil {
+		t.Errorf("Expected nil snapshot for nil pointer")
+	}
+	if created := diffSnapshots(nil, afterMap); created["amount"]["to"] != 30000.0 {
+		t.Errorf("Expected create diff to contain new values, got %v", created)
+	}
+}
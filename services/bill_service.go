@@ -0,0 +1,420 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+// Bill period statuses. A period is due from its reminder day until the due
+// date, and overdue after it until paid.
+const (
+	BillUpcoming = "upcoming"
+	BillDue      = "due"
+	BillPaid     = "paid"
+	BillOverdue  = "overdue"
+)
+
+const (
+	AlertBillDue     = "bill_due"
+	AlertBillOverdue = "bill_overdue"
+)
+
+// billAmountTolerance is how far below the estimate the payments in a period may
+// fall and still pay it, as a share of the estimate. Usage-based bills such as
+// electricity vary from month to month.
+const billAmountTolerance = 0.5
+
+var (
+	ErrBillNotFound = errors.New("bill not found")
+	ErrBillName     = errors.New("name is required")
+	ErrBillDueDay   = errors.New("due_day must be between 1 and 31")
+	ErrBillInterval = errors.New("interval_months must be between 1 and 12")
+	ErrBillAmount   = errors.New("amount cannot be negative")
+	ErrBillRemind   = errors.New("remind_days_before must be between 0 and 31")
+	ErrBillMatch    = errors.New("a bill needs a payee or category to recognize its payments")
+)
+
+// jakartaLocation is loaded once since due dates are computed in tight loops.
+var jakartaLocation, _ = time.LoadLocation("Asia/Jakarta")
+
+// BillPeriod is one occurrence of a bill. Amount is what was paid once paid,
+// and the estimate before that.
+type BillPeriod struct {
+	BillID         uint      `json:"bill_id"`
+	Name           string    `json:"name"`
+	DueDate        time.Time `json:"due_date"`
+	Amount         float64   `json:"amount"`
+	Status         string    `json:"status"`
+	TransactionIDs []uint    `json:"transaction_ids"`
+}
+
+// BillWithPeriod is a bill with the period that today falls in.
+type BillWithPeriod struct {
+	models.Bill
+	Current BillPeriod `json:"current"`
+}
+
+type BillService struct {
+	repo       *repositories.BillRepository
+	payees     *PayeeService
+	categories *CategoryService
+	accounts   *AccountService
+	alerts     *AlertService
+}
+
+func NewBillService(
+	repo *repositories.BillRepository,
+	payees *PayeeService,
+	categories *CategoryService,
+	accounts *AccountService,
+	alerts *AlertService,
+) *BillService {
+	return &BillService{repo, payees, categories, accounts, alerts}
+}
+
+// GetAll lists the user's bills with the status of their current period.
+func (s *BillService) GetAll(userID uint) ([]BillWithPeriod, error) {
+	bills, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	today := jakartaToday()
+	result := make([]BillWithPeriod, 0, len(bills))
+	for i := range bills {
+		k := billPeriodAt(&bills[i], today)
+		periods, err := s.periods(&bills[i], k, k, today)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, BillWithPeriod{Bill: bills[i], Current: periods[0]})
+	}
+	return result, nil
+}
+
+func (s *BillService) GetByID(id uint, userID uint) (*models.Bill, error) {
+	bill, err := s.repo.FindByID(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBillNotFound
+	}
+	return bill, err
+}
+
+func (s *BillService) Create(bill *models.Bill) error {
+	if err := s.validate(bill); err != nil {
+		return err
+	}
+	return s.repo.Create(bill)
+}
+
+func (s *BillService) Update(bill *models.Bill) error {
+	existing, err := s.GetByID(bill.ID, bill.UserID)
+	if err != nil {
+		return err
+	}
+	if bill.StartDate.IsZero() {
+		bill.StartDate = existing.StartDate
+	}
+	if err := s.validate(bill); err != nil {
+		return err
+	}
+	bill.CreatedAt = existing.CreatedAt
+	return s.repo.Update(bill)
+}
+
+func (s *BillService) Delete(id uint, userID uint) error {
+	if _, err := s.GetByID(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id, userID)
+}
+
+// GetPeriods returns the bill's last count periods up to and including the
+// current one, oldest first, followed by the next one.
+func (s *BillService) GetPeriods(id uint, userID uint, count int) ([]BillPeriod, error) {
+	bill, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := jakartaToday()
+	current := billPeriodAt(bill, today)
+	first := current - count + 1
+	if first < 0 {
+		first = 0
+	}
+	return s.periods(bill, first, current+1, today)
+}
+
+// Upcoming lists the periods of active bills due in the next days, plus any
+// still unpaid from the previous period, by due date. It also returns the total
+// still to pay.
+func (s *BillService) Upcoming(userID uint, days int) ([]BillPeriod, float64, error) {
+	bills, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	today := jakartaToday()
+	upcoming := []BillPeriod{}
+	total := 0.0
+	for i := range bills {
+		periods, err := s.openPeriods(&bills[i], today, today.AddDate(0, 0, days))
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, p := range periods {
+			upcoming = append(upcoming, p)
+			if p.Status != BillPaid {
+				total += p.Amount
+			}
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].DueDate.Before(upcoming[j].DueDate) })
+	return upcoming, roundCents(total), nil
+}
+
+// SendReminders notifies users of bills entering their reminder window and of
+// bills gone overdue, once per period each.
+func (s *BillService) SendReminders() {
+	bills, err := s.repo.FindActive()
+	if err != nil {
+		log.Printf("Warning: Failed to load bills: %v", err)
+		return
+	}
+	today := jakartaToday()
+	for i := range bills {
+		bill := &bills[i]
+		periods, err := s.openPeriods(bill, today, today.AddDate(0, 0, bill.RemindDaysBefore))
+		if err != nil {
+			log.Printf("Warning: Failed to check bill %d: %v", bill.ID, err)
+			continue
+		}
+		for _, p := range periods {
+			if n := billReminder(bill, p); n != nil {
+				if err := s.alerts.NotifyUser(bill.UserID, n, bill.Channels); err != nil {
+					log.Printf("Warning: Failed to send reminder for bill %d: %v", bill.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// openPeriods returns an active bill's periods due between today and until,
+// along with the previous period if it is still overdue.
+func (s *BillService) openPeriods(bill *models.Bill, today, until time.Time) ([]BillPeriod, error) {
+	if !bill.Active {
+		return nil, nil
+	}
+	first := billPeriodAt(bill, today) - 1
+	if first < 0 {
+		first = 0
+	}
+	last := first
+	for billDueDate(bill, last+1).Before(until.AddDate(0, 0, 1)) {
+		last++
+	}
+
+	periods, err := s.periods(bill, first, last, today)
+	if err != nil {
+		return nil, err
+	}
+	open := periods[:0]
+	for _, p := range periods {
+		if p.Status == BillOverdue || (!p.DueDate.Before(today) && p.DueDate.Before(until.AddDate(0, 0, 1))) {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+// periods loads the payments for periods first through last and works out
+// each period's status.
+func (s *BillService) periods(bill *models.Bill, first, last int, today time.Time) ([]BillPeriod, error) {
+	from, _ := billWindow(bill, first)
+	_, to := billWindow(bill, last)
+	payments, err := s.repo.FindPayments(bill, from, to)
+	if err != nil {
+		return nil, err
+	}
+	periods := make([]BillPeriod, 0, last-first+1)
+	for k := first; k <= last; k++ {
+		periods = append(periods, billPeriod(bill, k, payments, today))
+	}
+	return periods, nil
+}
+
+func (s *BillService) validate(bill *models.Bill) error {
+	bill.Name = strings.TrimSpace(bill.Name)
+	if bill.Name == "" {
+		return ErrBillName
+	}
+	if bill.DueDay < 1 || bill.DueDay > 31 {
+		return ErrBillDueDay
+	}
+	if bill.IntervalMonths == 0 {
+		bill.IntervalMonths = 1
+	}
+	if bill.IntervalMonths < 1 || bill.IntervalMonths > 12 {
+		return ErrBillInterval
+	}
+	if bill.Amount < 0 {
+		return ErrBillAmount
+	}
+	if bill.RemindDaysBefore < 0 || bill.RemindDaysBefore > 31 {
+		return ErrBillRemind
+	}
+	if err := s.alerts.validateChannels(bill.Channels, ""); err != nil {
+		return err
+	}
+
+	// Periods count from the start month; the day comes from DueDay
+	start := jakartaToday()
+	if !bill.StartDate.IsZero() {
+		start = bill.StartDate.In(jakartaLocation)
+	}
+	bill.StartDate = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, jakartaLocation)
+
+	if bill.CategoryID != nil && *bill.CategoryID == 0 {
+		bill.CategoryID = nil
+	}
+	if bill.CategoryID != nil {
+		if _, err := s.categories.GetForTransaction(*bill.CategoryID, bill.UserID, "expense"); err != nil {
+			return err
+		}
+	}
+	if bill.AccountID != nil && *bill.AccountID == 0 {
+		bill.AccountID = nil
+	}
+	if bill.AccountID != nil {
+		if _, err := s.accounts.GetByID(*bill.AccountID, bill.UserID); err != nil {
+			return err
+		}
+	}
+	if normalizePayee(bill.Payee) == "" && bill.CategoryID == nil {
+		return ErrBillMatch
+	}
+
+	// Resolved last, once the bill is known to be saved, since it may create the payee
+	bill.PayeeID = nil
+	if payee, err := s.payees.Resolve(bill.UserID, bill.Payee); err != nil {
+		return err
+	} else if payee != nil {
+		bill.PayeeID = &payee.ID
+		bill.Payee = payee.Name
+	}
+	return nil
+}
+
+// billDueDate is the due date of period k, counting from 0 at the start month.
+// A due day past the end of a short month falls on its last day.
+func billDueDate(bill *models.Bill, k int) time.Time {
+	start := bill.StartDate.In(jakartaLocation)
+	month := time.Date(start.Year(), start.Month()+time.Month(k*bill.IntervalMonths), 1, 0, 0, 0, 0, jakartaLocation)
+	day := bill.DueDay
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return month.AddDate(0, 0, day-1)
+}
+
+// billWindow is the span whose payments count towards period k: from halfway
+// after the previous due date to halfway before the next.
+func billWindow(bill *models.Bill, k int) (time.Time, time.Time) {
+	due, next := billDueDate(bill, k), billDueDate(bill, k+1)
+	prev := due.Add(-next.Sub(due))
+	if k > 0 {
+		prev = billDueDate(bill, k-1)
+	}
+	return due.Add(-due.Sub(prev) / 2), due.Add(next.Sub(due) / 2)
+}
+
+// billPeriodAt returns the period whose window contains day; days before the
+// first period belong to it. The period is estimated from the months since the
+// start and then moved at most a step or two, since windows reach only halfway
+// to the neighbouring due dates.
+func billPeriodAt(bill *models.Bill, day time.Time) int {
+	start, local := bill.StartDate.In(jakartaLocation), day.In(jakartaLocation)
+	months := (local.Year()-start.Year())*12 + int(local.Month()-start.Month())
+	k := 0
+	if months > 0 {
+		k = months / bill.IntervalMonths
+	}
+	for k > 0 {
+		if from, _ := billWindow(bill, k); !day.Before(from) {
+			break
+		}
+		k--
+	}
+	for {
+		if _, to := billWindow(bill, k); day.Before(to) {
+			return k
+		}
+		k++
+	}
+}
+
+// billPeriod totals the payments inside period k's window and derives its status
+// as of today, a Jakarta midnight.
+func billPeriod(bill *models.Bill, k int, payments []models.Transaction, today time.Time) BillPeriod {
+	due := billDueDate(bill, k)
+	from, to := billWindow(bill, k)
+	p := BillPeriod{BillID: bill.ID, Name: bill.Name, DueDate: due, Amount: bill.Amount, TransactionIDs: []uint{}}
+
+	paid := 0.0
+	for _, t := range payments {
+		if !t.Date.Before(from) && t.Date.Before(to) {
+			paid += t.Amount
+			p.TransactionIDs = append(p.TransactionIDs, t.ID)
+		}
+	}
+
+	switch {
+	case len(p.TransactionIDs) > 0 && paid >= bill.Amount*(1-billAmountTolerance):
+		p.Status = BillPaid
+		p.Amount = roundCents(paid)
+	case today.After(due):
+		p.Status = BillOverdue
+	case !today.Before(due.AddDate(0, 0, -bill.RemindDaysBefore)):
+		p.Status = BillDue
+	default:
+		p.Status = BillUpcoming
+	}
+	return p
+}
+
+// billReminder is the notification for a period that needs one, or nil.
+func billReminder(bill *models.Bill, p BillPeriod) *models.Notification {
+	key := fmt.Sprintf("bill:%d:%s:", bill.ID, p.DueDate.Format("2006-01-02"))
+	due := p.DueDate.Format("2 Jan 2006")
+	switch p.Status {
+	case BillDue:
+		return &models.Notification{
+			UserID:   bill.UserID,
+			Type:     AlertBillDue,
+			Title:    "Bill due soon: " + bill.Name,
+			Body:     fmt.Sprintf("%s of about %s is due on %s.", bill.Name, formatRupiah(p.Amount), due),
+			DedupKey: key + BillDue,
+		}
+	case BillOverdue:
+		return &models.Notification{
+			UserID:   bill.UserID,
+			Type:     AlertBillOverdue,
+			Title:    "Bill overdue: " + bill.Name,
+			Body:     fmt.Sprintf("%s of about %s was due on %s and no payment has been recorded.", bill.Name, formatRupiah(p.Amount), due),
+			DedupKey: key + BillOverdue,
+		}
+	}
+	return nil
+}
+
+// jakartaToday is midnight today in Jakarta.
+func jakartaToday() time.Time {
+	now := time.Now().In(jakartaLocation)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, jakartaLocation)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func jakartaDate(year int, month time.Month, day int) time.Time {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func TestBillDueDateClampsShortMonths(t *testing.T) {
+	bill := &models.Bill{DueDay: 31, IntervalMonths: 1, StartDate: jakartaDate(2024, 1, 1)}
+	want := []time.Time{jakartaDate(2024, 1, 31), jakartaDate(2024, 2, 29), jakartaDate(2024, 3, 31), jakartaDate(2024, 4, 30)}
+	for k, due := range want {
+		if got := billDueDate(bill, k); !got.Equal(due) {
+			t.Errorf("Period %d: due %v, want %v", k, got, due)
+		}
+	}
+
+	yearly := &models.Bill{DueDay: 15, IntervalMonths: 12, StartDate: jakartaDate(2023, 6, 1)}
+	if got := billDueDate(yearly, 1); !got.Equal(jakartaDate(2024, 6, 15)) {
+		t.Errorf("Expected the yearly bill due 15 Jun 2024, got %v", got)
+	}
+}
+
+func TestBillPeriodStatus(t *testing.T) {
+	bill := &models.Bill{ID: 1, Name: "PLN", Amount: 400000, DueDay: 20, IntervalMonths: 1, RemindDaysBefore: 3, StartDate: jakartaDate(2024, 1, 1)}
+	march := 2 // due 20 Mar 2024
+
+	cases := []struct {
+		name     string
+		today    time.Time
+		payments []models.Transaction
+		want     string
+	}{
+		{"early in the month", jakartaDate(2024, 3, 5), nil, BillUpcoming},
+		{"inside the reminder window", jakartaDate(2024, 3, 17), nil, BillDue},
+		{"on the due date", jakartaDate(2024, 3, 20), nil, BillDue},
+		{"after the due date", jakartaDate(2024, 3, 21), nil, BillOverdue},
+		{"paid a bit less than estimated", jakartaDate(2024, 3, 25), []models.Transaction{{ID: 9, Amount: 310000, Date: jakartaDate(2024, 3, 22)}}, BillPaid},
+		{"only a small payment", jakartaDate(2024, 3, 25), []models.Transaction{{ID: 9, Amount: 50000, Date: jakartaDate(2024, 3, 18)}}, BillOverdue},
+		{"paid last month's bill", jakartaDate(2024, 3, 10), []models.Transaction{{ID: 9, Amount: 400000, Date: jakartaDate(2024, 2, 21)}}, BillUpcoming},
+	}
+	for _, tc := range cases {
+		if got := billPeriod(bill, march, tc.payments, tc.today); got.Status != tc.want {
+			t.Errorf("%s: status %q, want %q", tc.name, got.Status, tc.want)
+		}
+	}
+}
+
+func TestBillPeriodAt(t *testing.T) {
+	bill := &models.Bill{DueDay: 10, IntervalMonths: 1, StartDate: jakartaDate(2024, 1, 1)}
+	if k := billPeriodAt(bill, jakartaDate(2024, 3, 24)); k != 2 {
+		t.Errorf("Expected 24 Mar to fall in the March period, got %d", k)
+	}
+	if k := billPeriodAt(bill, jakartaDate(2024, 3, 26)); k != 3 {
+		t.Errorf("Expected 26 Mar to fall in the April period, got %d", k)
+	}
+}
+
+func TestBillPeriodAtMatchesWindows(t *testing.T) {
+	for _, interval := range []int{1, 2, 3, 12} {
+		for _, dueDay := range []int{1, 15, 31} {
+			bill := &models.Bill{DueDay: dueDay, IntervalMonths: interval, StartDate: jakartaDate(2023, 11, 1)}
+			for day := jakartaDate(2023, 9, 1); day.Before(jakartaDate(2027, 1, 1)); day = day.AddDate(0, 0, 1) {
+				k := billPeriodAt(bill, day)
+				from, to := billWindow(bill, k)
+				if !day.Before(to) || (k > 0 && day.Before(from)) {
+					t.Fatalf("interval %d, due day %d: %s put in period %d (%s to %s)",
+						interval, dueDay, day.Format("2006-01-02"), k, from.Format("2006-01-02"), to.Format("2006-01-02"))
+				}
+			}
+		}
+	}
+}
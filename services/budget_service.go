@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	BudgetModeSimple   = "simple"
+	BudgetModeEnvelope = "envelope"
+)
+
+var ErrCategoryNotFound = errors.New("category not found")
+
+type BudgetService struct {
+	repo      *repositories.BudgetRepository
+	transRepo *repositories.TransactionRepository
+	userRepo  *repositories.UserRepository
+	catRepo   *repositories.CategoryRepository
+}
+
+func NewBudgetService(
+	repo *repositories.BudgetRepository,
+	transRepo *repositories.TransactionRepository,
+	userRepo *repositories.UserRepository,
+	catRepo *repositories.CategoryRepository,
+) *BudgetService {
+	return &BudgetService{repo, transRepo, userRepo, catRepo}
+}
+
+// Envelope is the state of one category envelope for a month.
+type Envelope struct {
+	CategoryID   uint    `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Carryover    float64 `json:"carryover"`
+	Assigned     float64 `json:"assigned"`
+	Activity     float64 `json:"activity"`
+	Available    float64 `json:"available"`
+}
+
+// EnvelopeMonth is the envelope budget for a single month.
+type EnvelopeMonth struct {
+	Income        float64    `json:"income"`
+	ReadyToAssign float64    `json:"ready_to_assign"`
+	Envelopes     []Envelope `json:"envelopes"`
+}
+
+func (s *BudgetService) SetMode(userID uint, mode string) error {
+	if mode != BudgetModeSimple && mode != BudgetModeEnvelope {
+		return errors.New("budget mode must be simple or envelope")
+	}
+	return s.userRepo.UpdateBudgetMode(userID, mode)
+}
+
+func (s *BudgetService) Assign(userID uint, categoryID uint, month int, year int, amount float64) (*models.Budget, error) {
+	cat, err := s.catRepo.FindByID(categoryID)
+	if err != nil || (cat.UserID != nil && *cat.UserID != userID) {
+		return nil, ErrCategoryNotFound
+	}
+
+	budget := &models.Budget{
+		UserID:     userID,
+		CategoryID: categoryID,
+		Year:       year,
+		Month:      month,
+		Amount:     amount,
+	}
+	if err := s.repo.Upsert(budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// GetAssignment returns the budget for a category and month, or nil if none is set.
+func (s *BudgetService) GetAssignment(userID uint, categoryID uint, month int, year int) *models.Budget {
+	budget, err := s.repo.FindByCategory(userID, categoryID, year, month)
+	if err != nil {
+		return nil
+	}
+	return budget
+}
+
+func (s *BudgetService) GetByID(id uint, userID uint) (*models.Budget, error) {
+	return s.repo.FindByID(id, userID)
+}
+
+func (s *BudgetService) Delete(id uint, userID uint) error {
+	return s.repo.Delete(id, userID)
+}
+
+// GetMonth returns the budget view for a month according to the user's budget mode.
+// A level above 0 rolls subcategory budgets and spending up to their parent at that depth.
+func (s *BudgetService) GetMonth(userID uint, month int, year int, level int) (map[string]interface{}, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.catRepo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	var target map[uint]uint
+	if level > 0 {
+		target = rollupMap(categories, level)
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	endDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc).AddDate(0, 1, 0)
+
+	spent, err := s.transRepo.GetMonthlyTotals(userID, "expense", endDate)
+	if err != nil {
+		return nil, err
+	}
+	spent = rollUpTotals(spent, target)
+
+	if user.BudgetMode != BudgetModeEnvelope {
+		budgets, err := s.repo.FindByPeriod(userID, year, month)
+		if err != nil {
+			return nil, err
+		}
+		budgets = rollUpBudgets(budgets, target)
+		return map[string]interface{}{
+			"mode":       BudgetModeSimple,
+			"month":      month,
+			"year":       year,
+			"categories": buildLimits(budgets, spent, names, year, month),
+		}, nil
+	}
+
+	assigned, err := s.repo.GetAssignedTotals(userID, year, month)
+	if err != nil {
+		return nil, err
+	}
+	assigned = rollUpTotals(assigned, target)
+	income, err := s.transRepo.GetMonthlyTotals(userID, "income", endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	envelopes := buildEnvelopes(assigned, spent, income, names, year, month)
+	return map[string]interface{}{
+		"mode":            BudgetModeEnvelope,
+		"month":           month,
+		"year":            year,
+		"income":          envelopes.Income,
+		"ready_to_assign": envelopes.ReadyToAssign,
+		"envelopes":       envelopes.Envelopes,
+	}, nil
+}
+
+// rollUpTotals points each total at its rolled-up category. Totals sharing a category
+// are summed later by the builders.
+func rollUpTotals(totals []repositories.MonthlyTotal, target map[uint]uint) []repositories.MonthlyTotal {
+	if target == nil {
+		return totals
+	}
+	rolled := make([]repositories.MonthlyTotal, 0, len(totals))
+	for _, t := range totals {
+		if id, ok := target[t.CategoryID]; ok {
+			t.CategoryID = id
+		}
+		rolled = append(rolled, t)
+	}
+	return rolled
+}
+
+// rollUpBudgets combines a month's budgets that roll up into the same category.
+// Combined budgets have no single row behind them, so their ID is 0.
+func rollUpBudgets(budgets []models.Budget, target map[uint]uint) []models.Budget {
+	if target == nil {
+		return budgets
+	}
+	rolled := []models.Budget{}
+	index := make(map[uint]int)
+	for _, b := range budgets {
+		if id, ok := target[b.CategoryID]; ok {
+			b.CategoryID = id
+		}
+		if i, ok := index[b.CategoryID]; ok {
+			rolled[i].Amount += b.Amount
+			rolled[i].ID = 0
+			continue
+		}
+		index[b.CategoryID] = len(rolled)
+		rolled = append(rolled, b)
+	}
+	return rolled
+}
+
+func buildLimits(budgets []models.Budget, spent []repositories.MonthlyTotal, names map[uint]string, year int, month int) []map[string]interface{} {
+	spentByCategory := make(map[uint]float64)
+	for _, t := range spent {
+		if t.Year == year && t.Month == month {
+			spentByCategory[t.CategoryID] += t.Total
+		}
+	}
+
+	limits := []map[string]interface{}{}
+	for _, b := range budgets {
+		limits = append(limits, map[string]interface{}{
+			"id":            b.ID,
+			"category_id":   b.CategoryID,
+			"category_name": names[b.CategoryID],
+			"budgeted":      b.Amount,
+			"spent":         spentByCategory[b.CategoryID],
+			"remaining":     b.Amount - spentByCategory[b.CategoryID],
+		})
+	}
+	return limits
+}
+
+// buildEnvelopes replays every month up to the requested one. Income goes into the
+// ready-to-assign pool, assignments move it into envelopes, spending draws envelopes
+// down, and whatever is left (including overspending) rolls into the next month.
+func buildEnvelopes(assigned, spent, income []repositories.MonthlyTotal, names map[uint]string, year int, month int) EnvelopeMonth {
+	target := monthIndex(year, month)
+	first := target
+	for _, set := range [][]repositories.MonthlyTotal{assigned, spent, income} {
+		for _, t := range set {
+			if idx := monthIndex(t.Year, t.Month); idx < first {
+				first = idx
+			}
+		}
+	}
+
+	assignedAt := groupByMonth(assigned)
+	spentAt := groupByMonth(spent)
+	incomeAt := groupByMonth(income)
+
+	var result EnvelopeMonth
+	var totalIncome, totalAssigned float64
+	available := make(map[uint]float64)
+
+	for idx := first; idx <= target; idx++ {
+		envelopes := make(map[uint]*Envelope)
+		get := func(categoryID uint) *Envelope {
+			if e, ok := envelopes[categoryID]; ok {
+				return e
+			}
+			e := &Envelope{CategoryID: categoryID, CategoryName: names[categoryID], Carryover: available[categoryID]}
+			envelopes[categoryID] = e
+			return e
+		}
+
+		for categoryID := range available {
+			get(categoryID)
+		}
+		for categoryID, amount := range assignedAt[idx] {
+			get(categoryID).Assigned += amount
+			totalAssigned += amount
+		}
+		for categoryID, amount := range spentAt[idx] {
+			get(categoryID).Activity -= amount
+		}
+
+		monthIncome := 0.0
+		for _, amount := range incomeAt[idx] {
+			monthIncome += amount
+		}
+		totalIncome += monthIncome
+
+		for categoryID, e := range envelopes {
+			e.Available = e.Carryover + e.Assigned + e.Activity
+			available[categoryID] = e.Available
+		}
+
+		if idx == target {
+			result.Income = monthIncome
+			result.ReadyToAssign = totalIncome - totalAssigned
+			result.Envelopes = make([]Envelope, 0, len(envelopes))
+			for _, e := range envelopes {
+				if e.Carryover == 0 && e.Assigned == 0 && e.Activity == 0 {
+					continue
+				}
+				result.Envelopes = append(result.Envelopes, *e)
+			}
+		}
+	}
+
+	sort.Slice(result.Envelopes, func(i, j int) bool {
+		return result.Envelopes[i].CategoryName < result.Envelopes[j].CategoryName
+	})
+	return result
+}
+
+func monthIndex(year int, month int) int {
+	return year*12 + month - 1
+}
+
+func groupByMonth(totals []repositories.MonthlyTotal) map[int]map[uint]float64 {
+	grouped := make(map[int]map[uint]float64)
+	for _, t := range totals {
+		idx := monthIndex(t.Year, t.Month)
+		if grouped[idx] == nil {
+			grouped[idx] = make(map[uint]float64)
+		}
+		grouped[idx][t.CategoryID] += t.Total
+	}
+	return grouped
+}
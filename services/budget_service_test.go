@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+func TestBuildEnvelopesRollover(t *testing.T) {
+	names := map[uint]string{1: "Food & Beverage", 2: "Rent"}
+	income := []repositories.MonthlyTotal{
+		{Year: 2024, Month: 1, CategoryID: 9, Total: 5000000},
+		{Year: 2024, Month: 2, CategoryID: 9, Total: 5000000},
+	}
+	assigned := []repositories.MonthlyTotal{
+		{Year: 2024, Month: 1, CategoryID: 1, Total: 1000000},
+		{Year: 2024, Month: 1, CategoryID: 2, Total: 2000000},
+		{Year: 2024, Month: 2, CategoryID: 1, Total: 1000000},
+	}
+	spent := []repositories.MonthlyTotal{
+		{Year: 2024, Month: 1, CategoryID: 1, Total: 700000},
+		{Year: 2024, Month: 1, CategoryID: 2, Total: 2500000},
+		{Year: 2024, Month: 2, CategoryID: 1, Total: 200000},
+	}
+
+	result := buildEnvelopes(assigned, spent, income, names, 2024, 2)
+
+	if result.Income != 5000000 {
+		t.Errorf("Expected income 5000000, got %v", result.Income)
+	}
+	if result.ReadyToAssign != 6000000 {
+		t.Errorf("Expected ready to assign 6000000, got %v", result.ReadyToAssign)
+	}
+
+	byCategory := make(map[uint]Envelope)
+	for _, e := range result.Envelopes {
+		byCategory[e.CategoryID] = e
+	}
+
+	food := byCategory[1]
+	if food.Carryover != 300000 || food.Available != 1100000 {
+		t.Errorf("Unexpected food envelope: %+v", food)
+	}
+
+	rent := byCategory[2]
+	if rent.Carryover != -500000 || rent.Available != -500000 {
+		t.Errorf("Expected overspent rent to carry -500000, got %+v", rent)
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antigravity/finance-tracker/ical"
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	// calendarHorizonDays is how far ahead the feed lists due dates.
+	calendarHorizonDays = 365
+	// calendarTokenBytes gives a 64 character hex token.
+	calendarTokenBytes = 32
+	calendarProdID     = "-//Antigravity//Finance Tracker//EN"
+	calendarUIDDomain  = "finance-tracker"
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// CalendarService serves each user's iCalendar feed of upcoming bills and
+// recurring charges not yet tracked as bills.
+type CalendarService struct {
+	repo          *repositories.CalendarRepository
+	billRepo      *repositories.BillRepository
+	bills         *BillService
+	subscriptions *SubscriptionService
+}
+
+func NewCalendarService(
+	repo *repositories.CalendarRepository,
+	billRepo *repositories.BillRepository,
+	bills *BillService,
+	subscriptions *SubscriptionService,
+) *CalendarService {
+	return &CalendarService{repo, billRepo, bills, subscriptions}
+}
+
+func (s *CalendarService) GetFeed(userID uint) (*models.CalendarFeed, error) {
+	feed, err := s.repo.FindByUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCalendarFeedNotFound
+	}
+	return feed, err
+}
+
+// Rotate issues the user a new feed token, creating the feed if needed. The old
+// token stops working at once. The returned feed is the only place the new
+// token can be read; just its hash is kept.
+func (s *CalendarService) Rotate(userID uint) (*models.CalendarFeed, error) {
+	buf := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+	feed := &models.CalendarFeed{UserID: userID, TokenHash: calendarTokenHash(token), Token: token}
+	if err := s.repo.Replace(feed); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// Disable removes the user's feed so its URL stops working.
+func (s *CalendarService) Disable(userID uint) error {
+	found, err := s.repo.DeleteByUser(userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// Render returns the feed behind token as an iCalendar document.
+func (s *CalendarService) Render(token string) ([]byte, error) {
+	if token == "" {
+		return nil, ErrCalendarFeedNotFound
+	}
+	feed, err := s.repo.FindByTokenHash(calendarTokenHash(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCalendarFeedNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	periods, _, err := s.bills.Upcoming(feed.UserID, calendarHorizonDays)
+	if err != nil {
+		return nil, err
+	}
+	bills, err := s.billRepo.FindAll(feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := s.subscriptions.Detect(feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := jakartaToday()
+	cal := ical.Calendar{
+		ProdID: calendarProdID,
+		Name:   "Bills and subscriptions",
+		Events: calendarEvents(periods, bills, subscriptions, today, today.AddDate(0, 0, calendarHorizonDays)),
+	}
+	return cal.Encode(time.Now()), nil
+}
+
+// calendarTokenHash is how a feed token is stored and looked up.
+func calendarTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// calendarEvents turns bill periods and untracked subscriptions' charges due up
+// to until into events, by date. Bill events remind as many days ahead as the
+// bill's own reminders.
+func calendarEvents(periods []BillPeriod, bills []models.Bill, subscriptions []Subscription, today, until time.Time) []ical.Event {
+	remind := map[uint]int{}
+	for _, b := range bills {
+		remind[b.ID] = b.RemindDaysBefore
+	}
+
+	events := []ical.Event{}
+	for _, p := range periods {
+		description := "Status: " + p.Status
+		if p.Status == BillPaid {
+			description = "Paid " + formatRupiah(p.Amount)
+		}
+		event := ical.Event{
+			UID:         fmt.Sprintf("bill-%d-%s@%s", p.BillID, p.DueDate.Format("20060102"), calendarUIDDomain),
+			Date:        p.DueDate,
+			Summary:     fmt.Sprintf("%s %s", p.Name, formatRupiah(p.Amount)),
+			Description: description,
+		}
+		if p.Status != BillPaid {
+			event.AlarmDaysBefore = remind[p.BillID]
+		}
+		events = append(events, event)
+	}
+
+	for _, sub := range subscriptions {
+		if sub.BillID != nil {
+			continue // Already listed as a bill
+		}
+		var step *cadence
+		for i := range cadences {
+			if cadences[i].Name == sub.Cadence {
+				step = &cadences[i]
+			}
+		}
+		if step == nil {
+			continue
+		}
+		next := sub.NextDate.In(jakartaLocation)
+		first := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, jakartaLocation)
+		for k := 0; ; k++ {
+			var date time.Time
+			if step.Months > 0 {
+				date = first.AddDate(0, step.Months*k, 0)
+			} else {
+				date = first.AddDate(0, 0, step.Days*k)
+			}
+			if date.After(until) {
+				break
+			}
+			if date.Before(today) {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:         fmt.Sprintf("subscription-%s-%s@%s", strings.NewReplacer(":", "-", " ", "-").Replace(sub.Key), date.Format("20060102"), calendarUIDDomain),
+				Date:        date,
+				Summary:     fmt.Sprintf("%s %s", sub.Name, formatRupiah(sub.Amount)),
+				Description: fmt.Sprintf("Expected %s charge, going by past ones", sub.Cadence),
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return events
+}
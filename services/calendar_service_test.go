@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestCalendarEvents(t *testing.T) {
+	today := jakartaDate(2024, 6, 20)
+	until := today.AddDate(0, 0, 60)
+	billID := uint(7)
+	bills := []models.Bill{{ID: billID, Name: "Electricity", RemindDaysBefore: 3}}
+	periods := []BillPeriod{
+		{BillID: billID, Name: "Electricity", DueDate: jakartaDate(2024, 6, 15), Amount: 350000, Status: BillPaid},
+		{BillID: billID, Name: "Electricity", DueDate: jakartaDate(2024, 7, 15), Amount: 400000, Status: BillUpcoming},
+	}
+	subscriptions := []Subscription{
+		{Key: "payee:1", Name: "Netflix", Cadence: "monthly", Amount: 186000, NextDate: jakartaDate(2024, 6, 5).AddDate(0, 1, 0)},
+		{Key: "name:gym club", Name: "Gym", Cadence: "weekly", Amount: 50000, NextDate: jakartaDate(2024, 6, 18)},
+		// Tracked as a bill already, so only the bill shows
+		{Key: "payee:2", Name: "Electricity", Cadence: "monthly", Amount: 400000, NextDate: jakartaDate(2024, 7, 15), BillID: &billID},
+	}
+
+	events := calendarEvents(periods, bills, subscriptions, today, until)
+
+	count := map[string]int{}
+	for i, e := range events {
+		if i > 0 && e.Date.Before(events[i-1].Date) {
+			t.Errorf("Events out of order at %d: %v before %v", i, e.Date, events[i-1].Date)
+		}
+		if e.Date.Before(today) && e.UID != "bill-7-20240615@finance-tracker" {
+			t.Errorf("Unexpected past event %+v", e)
+		}
+		if e.Date.After(until) {
+			t.Errorf("Event past the horizon: %+v", e)
+		}
+		count[e.Summary]++
+	}
+	if count["Electricity Rp350.000"] != 1 || count["Electricity Rp400.000"] != 1 {
+		t.Errorf("Expected both bill periods once, got %v", count)
+	}
+	// Jul 5 and Aug 5
+	if count["Netflix Rp186.000"] != 2 {
+		t.Errorf("Expected 2 Netflix charges, got %d", count["Netflix Rp186.000"])
+	}
+	// Jun 25 through Aug 13, skipping the one already past on Jun 18
+	if count["Gym Rp50.000"] != 8 {
+		t.Errorf("Expected 8 gym charges, got %d", count["Gym Rp50.000"])
+	}
+
+	for _, e := range events {
+		switch e.UID {
+		case "bill-7-20240615@finance-tracker":
+			if e.AlarmDaysBefore != 0 {
+				t.Errorf("Paid period should have no alarm: %+v", e)
+			}
+		case "bill-7-20240715@finance-tracker":
+			if e.AlarmDaysBefore != 3 {
+				t.Errorf("Upcoming period should remind 3 days ahead: %+v", e)
+			}
+		case "subscription-name-gym-club-20240625@finance-tracker":
+			return
+		}
+	}
+	t.Errorf("Missing stable UID for the first gym charge")
+}
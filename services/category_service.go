@@ -1,34 +1,493 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/antigravity/finance-tracker/models"
 	"github.com/antigravity/finance-tracker/repositories"
 )
 
+// UncategorizedCategory is the default category transactions fall back to when
+// their category is deleted.
+const UncategorizedCategory = "Uncategorized"
+
+const (
+	CategoryKindIncome  = "income"
+	CategoryKindExpense = "expense"
+	CategoryKindBoth    = "both"
+)
+
+// MaxCategoryDepth is how many levels categories may nest, e.g. Food & Beverage > Restaurants > Coffee.
+const MaxCategoryDepth = 3
+
+var (
+	ErrDefaultCategory     = errors.New("default categories cannot be changed or deleted")
+	ErrOwnCategoryHide     = errors.New("only default categories can be hidden; archive your own categories instead")
+	ErrCategoryNameEmpty   = errors.New("name cannot be empty")
+	ErrInvalidColor        = errors.New("color must be a hex value like #0ea5e9")
+	ErrInvalidParent       = errors.New("parent category is invalid")
+	ErrCategoryCycle       = errors.New("a category cannot be nested under itself or one of its subcategories")
+	ErrCategoryTooDeep     = fmt.Errorf("categories can be nested at most %d levels deep", MaxCategoryDepth)
+	ErrInvalidKind         = errors.New("kind must be income, expense or both")
+	ErrCategoryKindInUse   = errors.New("category already has transactions of the other type")
+	ErrCategoryKindMatch   = errors.New("category cannot be used for this transaction type")
+	ErrReplacementRequired = errors.New("category is in use; choose a replacement category or Uncategorized")
+	ErrInvalidReplacement  = errors.New("replacement category is invalid")
+	ErrReplacementKind     = errors.New("replacement category cannot be used for the category's transactions")
+)
+
+// CategoryNode is a category with its subcategories, as returned by the tree endpoint.
+type CategoryNode struct {
+	models.Category
+	Children []*CategoryNode `json:"children"`
+}
+
 type CategoryService struct {
-	repo *repositories.CategoryRepository
+	repo  *repositories.CategoryRepository
+	audit *AuditService
 }
 
-func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService {
-	return &CategoryService{repo}
+func NewCategoryService(repo *repositories.CategoryRepository, audit *AuditService) *CategoryService {
+	return &CategoryService{repo, audit}
 }
 
-func (s *CategoryService) Create(userID uint, name string) error {
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (s *CategoryService) Create(userID uint, name string, icon string, color string, parentID uint, kind string) (*models.Category, error) {
+	name, err := s.validateName(userID, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	if color != "" && !hexColor.MatchString(color) {
+		return nil, ErrInvalidColor
+	}
+	if kind == "" {
+		kind = CategoryKindBoth
+	}
+	if !validKind(kind) {
+		return nil, ErrInvalidKind
+	}
+
 	category := &models.Category{
 		UserID: &userID,
 		Name:   name,
+		Icon:   icon,
+		Color:  strings.ToLower(color),
+		Kind:   kind,
+	}
+	if parentID != 0 {
+		if err := s.validateParent(userID, 0, parentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = &parentID
 	}
-	return s.repo.Create(category)
+	if err := s.repo.Create(category); err != nil {
+		return nil, err
+	}
+	return category, nil
 }
 
-func (s *CategoryService) GetAll(userID uint) ([]models.Category, error) {
-	return s.repo.FindAll(userID)
+// Update renames, restyles or moves one of the user's own categories. Nil fields are
+// left as they are; a parentID of 0 makes the category top-level.
+func (s *CategoryService) Update(id uint, userID uint, name *string, icon *string, color *string, parentID *uint, kind *string) (*models.Category, error) {
+	category, err := s.ownCategory(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if name != nil {
+		trimmed, err := s.validateName(userID, *name, id)
+		if err != nil {
+			return nil, err
+		}
+		fields["name"] = trimmed
+	}
+	if icon != nil {
+		fields["icon"] = *icon
+	}
+	if color != nil {
+		if *color != "" && !hexColor.MatchString(*color) {
+			return nil, ErrInvalidColor
+		}
+		fields["color"] = strings.ToLower(*color)
+	}
+	if kind != nil && *kind != category.Kind {
+		if !validKind(*kind) {
+			return nil, ErrInvalidKind
+		}
+		if err := s.checkKindUnused(id, userID, *kind); err != nil {
+			return nil, err
+		}
+		fields["kind"] = *kind
+	}
+	if parentID != nil {
+		if *parentID == 0 {
+			fields["parent_id"] = nil
+		} else {
+			if err := s.validateParent(userID, id, *parentID); err != nil {
+				return nil, err
+			}
+			fields["parent_id"] = *parentID
+		}
+	}
+	if len(fields) == 0 {
+		return category, nil
+	}
+
+	if err := s.repo.UpdateFields(id, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
 }
 
-func (s *CategoryService) Delete(id uint) error {
-	return s.repo.Delete(id)
+// Merge moves every transaction, budget and alert rule from sourceID onto targetID
+// and then deletes the source, auditing each moved transaction. It returns the
+// number of transactions moved.
+func (s *CategoryService) Merge(actor Actor, sourceID uint, targetID uint) (int64, error) {
+	userID := actor.UserID
+	if _, err := s.ownCategory(sourceID, userID); err != nil {
+		return 0, err
+	}
+	if targetID == sourceID {
+		return 0, ErrInvalidReplacement
+	}
+	target, err := s.GetByID(targetID, userID)
+	if err != nil {
+		return 0, ErrInvalidReplacement
+	}
+	if err := s.checkReplacementKind(sourceID, userID, target); err != nil {
+		return 0, err
+	}
+	return s.reassign(actor, sourceID, targetID)
+}
+
+// SetArchived archives or unarchives one of the user's own categories. Archived
+// categories keep their transactions and history but drop out of pickers.
+func (s *CategoryService) SetArchived(id uint, userID uint, archived bool) (*models.Category, error) {
+	if _, err := s.ownCategory(id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateFields(id, map[string]interface{}{"archived": archived}); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+// SetHidden hides or shows a seeded default category for this user only.
+func (s *CategoryService) SetHidden(id uint, userID uint, hidden bool) error {
+	category, err := s.GetByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if category.UserID != nil {
+		return ErrOwnCategoryHide
+	}
+	if hidden {
+		return s.repo.Hide(userID, id)
+	}
+	return s.repo.Unhide(userID, id)
+}
+
+// GetByID returns a category the user can see: one of their own or a default.
+func (s *CategoryService) GetByID(id uint, userID uint) (*models.Category, error) {
+	category, err := s.repo.FindByID(id)
+	if err != nil || (category.UserID != nil && *category.UserID != userID) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// GetAll lists the categories to offer the user. Archived categories and hidden
+// defaults are left out unless asked for; hidden ones are flagged when included.
+// A kind of income or expense lists only categories usable for that type.
+func (s *CategoryService) GetAll(userID uint, includeArchived bool, includeHidden bool, kind string) ([]models.Category, error) {
+	categories, err := s.repo.FindVisible(userID, includeArchived, includeHidden, kind)
+	if err != nil || !includeHidden {
+		return categories, err
+	}
+
+	hiddenIDs, err := s.repo.FindHiddenIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[uint]bool, len(hiddenIDs))
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+	for i := range categories {
+		categories[i].Hidden = hidden[categories[i].ID]
+	}
+	return categories, nil
+}
+
+// GetTree returns the same categories as GetAll, nested under their parents.
+func (s *CategoryService) GetTree(userID uint, includeArchived bool, includeHidden bool, kind string) ([]*CategoryNode, error) {
+	categories, err := s.GetAll(userID, includeArchived, includeHidden, kind)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories), nil
+}
+
+// GetForTransaction returns a category the user can see and that fits the transaction type.
+func (s *CategoryService) GetForTransaction(id uint, userID uint, txType string) (*models.Category, error) {
+	category, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !kindAllows(category.Kind, txType) {
+		return nil, ErrCategoryKindMatch
+	}
+	return category, nil
+}
+
+// checkKindUnused refuses to narrow a category to kind while it holds transactions of the other type.
+func (s *CategoryService) checkKindUnused(id uint, userID uint, kind string) error {
+	var other string
+	switch kind {
+	case CategoryKindIncome:
+		other = CategoryKindExpense
+	case CategoryKindExpense:
+		other = CategoryKindIncome
+	default:
+		return nil
+	}
+	count, err := s.repo.CountTransactionsByType(id, userID, other)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryKindInUse
+	}
+	return nil
+}
+
+// checkReplacementKind refuses a replacement whose kind does not fit every
+// transaction in category id.
+func (s *CategoryService) checkReplacementKind(id uint, userID uint, replacement *models.Category) error {
+	err := s.checkKindUnused(id, userID, replacement.Kind)
+	if errors.Is(err, ErrCategoryKindInUse) {
+		return ErrReplacementKind
+	}
+	return err
+}
+
+func (s *CategoryService) validateParent(userID uint, id uint, parentID uint) error {
+	categories, err := s.repo.FindAll(userID)
+	if err != nil {
+		return err
+	}
+	return checkParent(indexCategories(categories), id, parentID)
+}
+
+// ownCategory returns a category the user owns, refusing defaults.
+func (s *CategoryService) ownCategory(id uint, userID uint) (*models.Category, error) {
+	category, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	if category.UserID == nil {
+		return nil, ErrDefaultCategory
+	}
+	if *category.UserID != userID {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// validateName trims a name for the user's category excludeID (0 for a new one)
+// and checks it is neither blank nor taken.
+func (s *CategoryService) validateName(userID uint, name string, excludeID uint) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrCategoryNameEmpty
+	}
+	if err := s.checkNameFree(userID, name, excludeID); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (s *CategoryService) checkNameFree(userID uint, name string, excludeID uint) error {
+	count, err := s.repo.CountByName(userID, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryNameTaken
+	}
+	return nil
+}
+
+// Delete removes one of the user's own categories. When anything still uses it
+// (see CategoryRepository.CountUsage), it is moved to replacementID, or to the default
+// "Uncategorized" category when uncategorized is set; without either the delete is
+// refused. Each moved transaction is audited, and the number moved is returned.
+func (s *CategoryService) Delete(actor Actor, id uint, replacementID uint, uncategorized bool) (int64, error) {
+	userID := actor.UserID
+	if _, err := s.ownCategory(id, userID); err != nil {
+		return 0, err
+	}
+
+	if uncategorized && replacementID == 0 {
+		fallback, err := s.repo.FindDefaultByName(UncategorizedCategory)
+		if err != nil {
+			return 0, err
+		}
+		replacementID = fallback.ID
+	}
+
+	if replacementID == 0 {
+		used, err := s.repo.CountUsage(id, userID)
+		if err != nil {
+			return 0, err
+		}
+		if used > 0 {
+			return 0, ErrReplacementRequired
+		}
+	} else if replacementID == id {
+		return 0, ErrInvalidReplacement
+	} else {
+		replacement, err := s.GetByID(replacementID, userID)
+		if err != nil {
+			return 0, ErrInvalidReplacement
+		}
+		if err := s.checkReplacementKind(id, userID, replacement); err != nil {
+			return 0, err
+		}
+	}
+
+	return s.reassign(actor, id, replacementID)
+}
+
+// reassign deletes the category, moving whatever uses it to replacementID, and
+// audits each moved transaction.
+func (s *CategoryService) reassign(actor Actor, id uint, replacementID uint) (int64, error) {
+	moved, err := s.repo.DeleteAndReassign(id, actor.UserID, replacementID)
+	if err != nil {
+		return 0, err
+	}
+	s.audit.RecordTransactionUpdates(actor, moved, func(t *models.Transaction) {
+		t.CategoryID = replacementID
+	})
+	return int64(len(moved)), nil
 }
 
 func (s *CategoryService) GetOrCreateByName(userID uint, name string) (*models.Category, error) {
 	return s.repo.GetOrCreateByName(userID, name)
 }
+
+func indexCategories(categories []models.Category) map[uint]models.Category {
+	byID := make(map[uint]models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	return byID
+}
+
+func parentOf(c models.Category) uint {
+	if c.ParentID == nil {
+		return 0
+	}
+	return *c.ParentID
+}
+
+// checkParent reports whether categoryID (0 for a new category) can be placed under
+// parentID without creating a cycle or nesting deeper than MaxCategoryDepth.
+func checkParent(byID map[uint]models.Category, categoryID uint, parentID uint) error {
+	if _, ok := byID[parentID]; !ok {
+		return ErrInvalidParent
+	}
+
+	depth := 0
+	for id := parentID; id != 0; {
+		if id == categoryID {
+			return ErrCategoryCycle
+		}
+		depth++
+		parent, ok := byID[id]
+		if !ok || depth > MaxCategoryDepth {
+			break
+		}
+		id = parentOf(parent)
+	}
+
+	if depth+subtreeHeight(byID, categoryID, 0) > MaxCategoryDepth {
+		return ErrCategoryTooDeep
+	}
+	return nil
+}
+
+// subtreeHeight counts the levels from id down to its deepest descendant, itself included.
+func subtreeHeight(byID map[uint]models.Category, id uint, depth int) int {
+	if id == 0 || depth > MaxCategoryDepth {
+		return 1
+	}
+	height := 1
+	for _, c := range byID {
+		if parentOf(c) == id {
+			if h := 1 + subtreeHeight(byID, c.ID, depth+1); h > height {
+				height = h
+			}
+		}
+	}
+	return height
+}
+
+// rollupMap maps every category onto its ancestor at the given level (1 is top-level).
+// Categories at or above that level map to themselves.
+func rollupMap(categories []models.Category, level int) map[uint]uint {
+	byID := indexCategories(categories)
+	target := make(map[uint]uint, len(categories))
+	for _, c := range categories {
+		// chain runs from the category up to its root
+		chain := []uint{c.ID}
+		for id := parentOf(c); id != 0 && len(chain) <= MaxCategoryDepth; {
+			chain = append(chain, id)
+			parent, ok := byID[id]
+			if !ok {
+				break
+			}
+			id = parentOf(parent)
+		}
+		target[c.ID] = c.ID
+		if level > 0 && len(chain) > level {
+			target[c.ID] = chain[len(chain)-level]
+		}
+	}
+	return target
+}
+
+// buildCategoryTree nests categories under their parents, keeping the input order.
+// Categories whose parent is not in the list (archived or hidden) become roots.
+func buildCategoryTree(categories []models.Category) []*CategoryNode {
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &CategoryNode{Category: c, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if parent, ok := nodes[parentOf(c)]; ok && parent != node {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+func validKind(kind string) bool {
+	return kind == CategoryKindIncome || kind == CategoryKindExpense || kind == CategoryKindBoth
+}
+
+// kindAllows reports whether a category of the given kind can hold a transaction of txType.
+// Categories created before kinds existed have an empty kind and allow both.
+func kindAllows(kind string, txType string) bool {
+	return kind == "" || kind == CategoryKindBoth || kind == txType
+}
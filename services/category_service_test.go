@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func categoryUnder(id uint, name string, parentID uint) models.Category {
+	c := models.Category{ID: id, Name: name}
+	if parentID != 0 {
+		c.ParentID = &parentID
+	}
+	return c
+}
+
+func TestCheckParent(t *testing.T) {
+	byID := indexCategories([]models.Category{
+		categoryUnder(1, "Food & Beverage", 0),
+		categoryUnder(2, "Restaurants", 1),
+		categoryUnder(3, "Coffee", 2),
+		categoryUnder(4, "Transport", 0),
+		categoryUnder(5, "Fuel", 4),
+	})
+
+	if err := checkParent(byID, 0, 2); err != nil {
+		t.Errorf("Expected new category under Restaurants to be allowed, got %v", err)
+	}
+	if err := checkParent(byID, 0, 3); err != ErrCategoryTooDeep {
+		t.Errorf("Expected ErrCategoryTooDeep under Coffee, got %v", err)
+	}
+	if err := checkParent(byID, 1, 3); err != ErrCategoryCycle {
+		t.Errorf("Expected ErrCategoryCycle moving Food under Coffee, got %v", err)
+	}
+	if err := checkParent(byID, 1, 1); err != ErrCategoryCycle {
+		t.Errorf("Expected ErrCategoryCycle nesting Food under itself, got %v", err)
+	}
+	// Transport > Fuel is two levels, so it fits under a top-level category but not a second-level one
+	if err := checkParent(byID, 4, 1); err != nil {
+		t.Errorf("Expected Transport under Food to be allowed, got %v", err)
+	}
+	if err := checkParent(byID, 4, 2); err != ErrCategoryTooDeep {
+		t.Errorf("Expected ErrCategoryTooDeep moving Transport under Restaurants, got %v", err)
+	}
+	if err := checkParent(byID, 0, 99); err != ErrInvalidParent {
+		t.Errorf("Expected ErrInvalidParent for unknown parent, got %v", err)
+	}
+}
+
+func TestRollupMap(t *testing.T) {
+	categories := []models.Category{
+		categoryUnder(1, "Food & Beverage", 0),
+		categoryUnder(2, "Restaurants", 1),
+		categoryUnder(3, "Coffee", 2),
+		categoryUnder(4, "Rent", 0),
+	}
+
+	top := rollupMap(categories, 1)
+	for id, want := range map[uint]uint{1: 1, 2: 1, 3: 1, 4: 4} {
+		if top[id] != want {
+			t.Errorf("Level 1: expected %d to roll up to %d, got %d", id, want, top[id])
+		}
+	}
+
+	second := rollupMap(categories, 2)
+	for id, want := range map[uint]uint{1: 1, 2: 2, 3: 2, 4: 4} {
+		if second[id] != want {
+			t.Errorf("Level 2: expected %d to roll up to %d, got %d", id, want, second[id])
+		}
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	tree := buildCategoryTree([]models.Category{
+		categoryUnder(1, "Food & Beverage", 0),
+		categoryUnder(2, "Groceries", 1),
+		categoryUnder(3, "Restaurants", 1),
+		categoryUnder(5, "Fuel", 4), // parent archived, so not in the list
+	})
+
+	if len(tree) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(tree))
+	}
+	if tree[0].ID != 1 || len(tree[0].Children) != 2 {
+		t.Errorf("Expected Food & Beverage with 2 children, got %+v", tree[0])
+	}
+	if tree[1].ID != 5 {
+		t.Errorf("Expected orphaned Fuel to become a root, got %d", tree[1].ID)
+	}
+}
+
+func TestKindAllows(t *testing.T) {
+	cases := []struct {
+		kind   string
+		txType string
+		want   bool
+	}{
+		{CategoryKindIncome, "income", true},
+		{CategoryKindIncome, "expense", false},
+		{CategoryKindExpense, "income", false},
+		{CategoryKindBoth, "expense", true},
+		{"", "income", true},
+	}
+	for _, tc := range cases {
+		if got := kindAllows(tc.kind, tc.txType); got != tc.want {
+			t.Errorf("kindAllows(%q, %q) = %v, want %v", tc.kind, tc.txType, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	// duplicateWindow is how far apart two dates can be and still be one purchase
+	// entered twice, e.g. on the day it was made and the day it posted.
+	duplicateWindow = 3 * 24 * time.Hour
+	// duplicateThreshold is the score above which a pair is reported.
+	duplicateThreshold = 0.75
+)
+
+var ErrDuplicatePair = errors.New("a duplicate pair needs two different transactions")
+
+// DuplicatePair is two transactions that look like the same one entered twice.
+// First is the older entry.
+type DuplicatePair struct {
+	First  models.Transaction `json:"first"`
+	Second models.Transaction `json:"second"`
+	Score  float64            `json:"score"`
+}
+
+// DuplicateService finds transactions that were likely entered twice, from
+// overlapping imports or quick-adds on several devices.
+type DuplicateService struct {
+	repo *repositories.DuplicateRepository
+}
+
+func NewDuplicateService(repo *repositories.DuplicateRepository) *DuplicateService {
+	return &DuplicateService{repo}
+}
+
+// Flag points t at the closest existing transaction it likely duplicates, if
+// any. It runs before t is saved and never blocks the write.
+func (s *DuplicateService) Flag(t *models.Transaction) {
+	t.DuplicateOf = nil
+	candidates, err := s.repo.FindNear(t.UserID, t.Type, t.Amount, t.Date.Add(-duplicateWindow), t.Date.Add(duplicateWindow))
+	if err != nil {
+		return
+	}
+	best := 0.0
+	for i := range candidates {
+		if candidates[i].ID == t.ID {
+			continue
+		}
+		if score := duplicateScore(&candidates[i], t); score >= duplicateThreshold && score > best {
+			best = score
+			t.DuplicateOf = &candidates[i].ID
+		}
+	}
+}
+
+// Find lists likely duplicate pairs among transactions dated within the last
+// days, best matches first. Dismissed pairs are left out.
+func (s *DuplicateService) Find(userID uint, days int) ([]DuplicatePair, error) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	since := time.Now().In(loc).AddDate(0, 0, -days)
+	transactions, err := s.repo.FindSince(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	dismissals, err := s.repo.FindDismissals(userID)
+	if err != nil {
+		return nil, err
+	}
+	dismissed := make(map[[2]uint]bool, len(dismissals))
+	for _, d := range dismissals {
+		dismissed[[2]uint{d.TransactionID, d.OtherID}] = true
+	}
+
+	pairs := findDuplicates(transactions, dismissed)
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Score > pairs[j].Score })
+	return pairs, nil
+}
+
+// Dismiss records that two transactions are distinct so they are not suggested again.
+func (s *DuplicateService) Dismiss(userID uint, a, b uint) error {
+	if a == b {
+		return ErrDuplicatePair
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return s.repo.Dismiss(&models.DuplicateDismissal{UserID: userID, TransactionID: a, OtherID: b})
+}
+
+// findDuplicates compares each transaction with the later ones of the same type
+// and amount inside the window. transactions must be ordered by type, amount and date.
+func findDuplicates(transactions []models.Transaction, dismissed map[[2]uint]bool) []DuplicatePair {
+	pairs := []DuplicatePair{}
+	for i := range transactions {
+		a := &transactions[i]
+		for j := i + 1; j < len(transactions); j++ {
+			b := &transactions[j]
+			if b.Type != a.Type || b.Amount != a.Amount || b.Date.Sub(a.Date) > duplicateWindow {
+				break
+			}
+			if dismissed[orderedPair(a.ID, b.ID)] {
+				continue
+			}
+			if score := duplicateScore(a, b); score >= duplicateThreshold {
+				pairs = append(pairs, DuplicatePair{First: *a, Second: *b, Score: score})
+			}
+		}
+	}
+	return pairs
+}
+
+// duplicateScore rates how likely b is a second entry of a, from 0 to 1. The
+// amount and type must match exactly and the accounts must not differ; the
+// rest of the score comes from how close the dates are and how alike the
+// payees and descriptions read.
+func duplicateScore(a, b *models.Transaction) float64 {
+	if a.Type != b.Type || a.Amount != b.Amount {
+		return 0
+	}
+	if a.AccountID != nil && b.AccountID != nil && *a.AccountID != *b.AccountID {
+		return 0
+	}
+	// Both sides matched the same bank statement, so they are distinct lines
+	if a.Status == TransactionReconciled && b.Status == TransactionReconciled {
+		return 0
+	}
+	gap := math.Abs(a.Date.Sub(b.Date).Hours()) / 24
+	if gap > duplicateWindow.Hours()/24 {
+		return 0
+	}
+
+	closeness := 1 - gap/(duplicateWindow.Hours()/24+1)
+	similarity := textSimilarity(a.Payee+" "+a.Description, b.Payee+" "+b.Description)
+	if a.PayeeID != nil && b.PayeeID != nil && *a.PayeeID == *b.PayeeID {
+		similarity = 1
+	}
+	return math.Round((0.4+0.3*closeness+0.3*similarity)*100) / 100
+}
+
+// textSimilarity is the share of words two texts have in common, ignoring case,
+// punctuation and numbers such as store or reference codes. Two blank texts are
+// alike; a blank and a non-blank one are not.
+func textSimilarity(a, b string) float64 {
+	wordsA, wordsB := wordSet(a), wordSet(b)
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+func wordSet(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		if strings.IndexFunc(w, unicode.IsLetter) >= 0 {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+func orderedPair(a, b uint) [2]uint {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint{a, b}
+}
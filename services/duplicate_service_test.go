@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestDuplicateScore(t *testing.T) {
+	day := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	account1, account2 := uint(1), uint(2)
+	base := models.Transaction{ID: 1, Type: "expense", Amount: 25000, Payee: "Indomaret", Description: "Snacks", Date: day}
+
+	cases := []struct {
+		name string
+		edit func(t *models.Transaction)
+		dupe bool
+	}{
+		{"same entry", func(t *models.Transaction) {}, true},
+		{"next day, store number", func(t *models.Transaction) { t.Payee = "INDOMARET 123"; t.Date = day.AddDate(0, 0, 1) }, true},
+		{"same day, other shop", func(t *models.Transaction) { t.Payee = "Alfamart"; t.Description = "Drinks" }, false},
+		{"other amount", func(t *models.Transaction) { t.Amount = 25500 }, false},
+		{"other account", func(t *models.Transaction) { t.AccountID = &account2 }, false},
+		{"too far apart", func(t *models.Transaction) { t.Date = day.AddDate(0, 0, 4) }, false},
+	}
+	for _, tc := range cases {
+		a := base
+		a.AccountID = &account1
+		b := base
+		b.ID = 2
+		tc.edit(&b)
+		if got := duplicateScore(&a, &b) >= duplicateThreshold; got != tc.dupe {
+			t.Errorf("%s: duplicate = %v, want %v (score %v)", tc.name, got, tc.dupe, duplicateScore(&a, &b))
+		}
+	}
+}
+
+func TestFindDuplicatesSkipsDismissed(t *testing.T) {
+	day := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	transactions := []models.Transaction{
+		{ID: 1, Type: "expense", Amount: 18000, Payee: "Gojek", Date: day},
+		{ID: 4, Type: "expense", Amount: 18000, Payee: "Gojek", Date: day.Add(2 * time.Hour)},
+		{ID: 2, Type: "expense", Amount: 50000, Description: "Dinner", Date: day},
+		{ID: 3, Type: "expense", Amount: 50000, Description: "Dinner", Date: day},
+	}
+
+	pairs := findDuplicates(transactions, map[[2]uint]bool{{2, 3}: true})
+	if len(pairs) != 1 || pairs[0].First.ID != 1 || pairs[0].Second.ID != 4 {
+		t.Errorf("Expected only the Gojek pair, got %+v", pairs)
+	}
+}
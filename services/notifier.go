@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+// Notifier delivers a notification to a user over an external channel.
+// The in-app inbox is always written; notifiers are selected per rule.
+type Notifier interface {
+	Channel() string
+	Notify(user *models.User, rule *models.AlertRule, n *models.Notification) error
+}
+
+// EmailNotifier sends notifications through an SMTP relay.
+type EmailNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewEmailNotifierFromEnv reads SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASSWORD and SMTP_FROM.
+// It returns nil when SMTP_HOST is not configured.
+func NewEmailNotifierFromEnv() *EmailNotifier {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USER")
+	}
+	return &EmailNotifier{
+		host:     host,
+		port:     port,
+		username: os.Getenv("SMTP_USER"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     from,
+	}
+}
+
+func (e *EmailNotifier) Channel() string {
+	return "email"
+}
+
+func (e *EmailNotifier) Notify(user *models.User, rule *models.AlertRule, n *models.Notification) error {
+	var auth smtp.Auth
+	if e.username != "" {
+		auth = smtp.PlainAuth("", e.username, e.password, e.host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		e.from, user.Email, n.Title, n.Body)
+	return smtp.SendMail(e.host+":"+e.port, auth, e.from, []string{user.Email}, []byte(msg))
+}
+
+var (
+	ErrWebhookURL     = errors.New("webhook_url must be an https URL")
+	ErrWebhookAddress = errors.New("webhook address is not public")
+)
+
+// WebhookNotifier posts the notification as JSON to the URL configured on the rule.
+// Users choose the URL, so it only connects to public addresses over https.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: publicTransport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("too many webhook redirects")
+			}
+			return validateWebhookURL(req.URL.String())
+		},
+	}}
+}
+
+// publicTransport only connects to public addresses and never through a proxy,
+// for requests to URLs that users choose.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// Control sees the address after DNS resolution, so a hostname that
+		// resolves to an internal address is caught too
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrWebhookAddress
+			}
+			return nil
+		},
+	}
+	return &http.Transport{Proxy: nil, DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second}
+}
+
+// validateWebhookURL checks that a webhook URL is https with a host. Whether the
+// host is public is checked when connecting.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrWebhookURL
+	}
+	return nil
+}
+
+// publicIP reports whether ip is a globally routable unicast address: not
+// loopback, private, link-local (which includes cloud metadata), shared or unspecified.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	// Carrier-grade NAT range, used for internal networks by some providers
+	_, shared, _ := net.ParseCIDR("100.64.0.0/10")
+	return !shared.Contains(ip)
+}
+
+func (w *WebhookNotifier) Channel() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(user *models.User, rule *models.AlertRule, n *models.Notification) error {
+	if rule.WebhookURL == "" {
+		return errors.New("webhook url is not configured")
+	}
+	if err := validateWebhookURL(rule.WebhookURL); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"user_id":    user.ID,
+		"rule_id":    rule.ID,
+		"type":       n.Type,
+		"title":      n.Title,
+		"body":       n.Body,
+		"created_at": n.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(rule.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
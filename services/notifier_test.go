@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestPublicIP(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::ffff:10.0.0.1": false,
+	}
+	for addr, want := range cases {
+		if got := publicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("publicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for _, raw := range []string{"http://example.com/hook", "https://", "ftp://example.com", "example.com"} {
+		if err := validateWebhookURL(raw); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+	if err := validateWebhookURL("https://example.com/hook"); err != nil {
+		t.Errorf("Expected https URL to be accepted: %v", err)
+	}
+}
+
+func TestWebhookNotifierRefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier()
+	err := notifier.Notify(&models.User{ID: 1}, &models.AlertRule{WebhookURL: server.URL}, &models.Notification{})
+	if !errors.Is(err, ErrWebhookAddress) {
+		t.Errorf("Expected loopback to be refused, got %v", err)
+	}
+	if called {
+		t.Error("Webhook reached a loopback server")
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+var (
+	ErrPayeeNotFound   = errors.New("payee not found")
+	ErrPayeeAliasTaken = errors.New("another payee already uses this name or alias")
+)
+
+// payeeNoise are legal-entity words that vary between statements for the same merchant.
+var payeeNoise = map[string]bool{
+	"pt": true, "tbk": true, "cv": true, "persero": true, "ltd": true, "inc": true,
+}
+
+const defaultTopPayees = 10
+
+type PayeeService struct {
+	repo    *repositories.PayeeRepository
+	catRepo *repositories.CategoryRepository
+	audit   *AuditService
+}
+
+func NewPayeeService(repo *repositories.PayeeRepository, catRepo *repositories.CategoryRepository, audit *AuditService) *PayeeService {
+	return &PayeeService{repo, catRepo, audit}
+}
+
+func (s *PayeeService) GetAll(userID uint) ([]models.Payee, error) {
+	return s.repo.FindAll(userID)
+}
+
+func (s *PayeeService) GetByID(id uint, userID uint) (*models.Payee, error) {
+	payee, err := s.repo.FindByID(id, userID)
+	if err != nil {
+		return nil, ErrPayeeNotFound
+	}
+	return payee, nil
+}
+
+func (s *PayeeService) Create(payee *models.Payee) error {
+	if err := s.validate(payee); err != nil {
+		return err
+	}
+	return s.repo.Create(payee)
+}
+
+// Update saves changes to a payee and renames its transactions to match,
+// auditing each renamed transaction.
+func (s *PayeeService) Update(actor Actor, payee *models.Payee) error {
+	existing, err := s.GetByID(payee.ID, payee.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(payee); err != nil {
+		return err
+	}
+	payee.CreatedAt = existing.CreatedAt
+	if err := s.repo.Update(payee); err != nil {
+		return err
+	}
+	if payee.Name == existing.Name {
+		return nil
+	}
+	renamed, err := s.repo.UpdateTransactionNames(payee.ID, payee.UserID, payee.Name)
+	if err != nil {
+		return err
+	}
+	s.audit.RecordTransactionUpdates(actor, renamed, func(t *models.Transaction) {
+		t.Payee = payee.Name
+	})
+	return nil
+}
+
+// Delete removes one of the user's payees, auditing each transaction it is unlinked from.
+func (s *PayeeService) Delete(actor Actor, id uint) error {
+	if _, err := s.GetByID(id, actor.UserID); err != nil {
+		return err
+	}
+	unlinked, err := s.repo.Delete(id, actor.UserID)
+	if err != nil {
+		return err
+	}
+	s.audit.RecordTransactionUpdates(actor, unlinked, func(t *models.Transaction) {
+		t.PayeeID = nil
+	})
+	return nil
+}
+
+// GetTop returns the payees with the largest totals of txType in [startDate, endDate).
+func (s *PayeeService) GetTop(userID uint, txType string, startDate, endDate time.Time, limit int) ([]repositories.PayeeTotal, error) {
+	if limit <= 0 {
+		limit = defaultTopPayees
+	}
+	return s.repo.FindTop(userID, txType, startDate, endDate, limit)
+}
+
+// Resolve finds the user's payee for a name typed by the user or set by a rule,
+// creating one if nothing matches. It returns nil for a blank name.
+func (s *PayeeService) Resolve(userID uint, name string) (*models.Payee, error) {
+	if normalizePayee(name) == "" {
+		return nil, nil
+	}
+	payees, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	if payee := matchPayee(payees, name, false); payee != nil {
+		return payee, nil
+	}
+
+	payee := &models.Payee{UserID: userID, Name: payeeDisplayName(name)}
+	if err := s.repo.Create(payee); err != nil {
+		return nil, err
+	}
+	return payee, nil
+}
+
+// Apply links a new transaction to a payee: the one named by t.Payee (created if
+// needed), or else one whose name or alias appears in the description. A linked
+// payee's default category fills in a missing category.
+func (s *PayeeService) Apply(t *models.Transaction) error {
+	var payee *models.Payee
+	if t.Payee != "" {
+		resolved, err := s.Resolve(t.UserID, t.Payee)
+		if err != nil {
+			return err
+		}
+		payee = resolved
+	} else if t.Description != "" {
+		payees, err := s.repo.FindAll(t.UserID)
+		if err != nil {
+			return err
+		}
+		payee = matchPayee(payees, t.Description, true)
+	}
+	if payee == nil {
+		return nil
+	}
+
+	t.PayeeID = &payee.ID
+	t.Payee = payee.Name
+	if t.CategoryID == 0 && payee.DefaultCategoryID != nil {
+		if cat, err := s.catRepo.FindByID(*payee.DefaultCategoryID); err == nil && kindAllows(cat.Kind, t.Type) {
+			t.CategoryID = cat.ID
+		}
+	}
+	return nil
+}
+
+func (s *PayeeService) validate(payee *models.Payee) error {
+	payee.Name = strings.TrimSpace(payee.Name)
+	if normalizePayee(payee.Name) == "" {
+		return errors.New("name is required")
+	}
+	aliases := models.StringList{}
+	for _, alias := range payee.Aliases {
+		if alias = strings.TrimSpace(alias); normalizePayee(alias) != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	payee.Aliases = aliases
+
+	if payee.DefaultCategoryID != nil {
+		cat, err := s.catRepo.FindByID(*payee.DefaultCategoryID)
+		if err != nil || (cat.UserID != nil && *cat.UserID != payee.UserID) {
+			return ErrCategoryNotFound
+		}
+	}
+
+	others, err := s.repo.FindAll(payee.UserID)
+	if err != nil {
+		return err
+	}
+	for _, name := range append([]string{payee.Name}, payee.Aliases...) {
+		if match := matchPayee(others, name, false); match != nil && match.ID != payee.ID {
+			return ErrPayeeAliasTaken
+		}
+	}
+	return nil
+}
+
+// normalizePayee reduces a payee name to a matching key: lowercase words with
+// punctuation, store numbers and legal-entity words removed, so "INDOMARET 123"
+// and "Indomaret, PT" both become "indomaret".
+func normalizePayee(name string) string {
+	name = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if payeeNoise[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return strings.Join(kept, " ")
+}
+
+// matchPayee finds the payee whose name or an alias normalizes to the same key as
+// text. With partial set, a name or alias that appears as whole words anywhere in
+// text also matches, and the longest such match wins.
+func matchPayee(payees []models.Payee, text string, partial bool) *models.Payee {
+	key := normalizePayee(text)
+	if key == "" {
+		return nil
+	}
+
+	var best *models.Payee
+	bestLen := 0
+	for i := range payees {
+		for _, candidate := range append([]string{payees[i].Name}, payees[i].Aliases...) {
+			candidateKey := normalizePayee(candidate)
+			if candidateKey == "" {
+				continue
+			}
+			if candidateKey == key {
+				return &payees[i]
+			}
+			if partial && strings.Contains(" "+key+" ", " "+candidateKey+" ") && len(candidateKey) > bestLen {
+				best, bestLen = &payees[i], len(candidateKey)
+			}
+		}
+	}
+	return best
+}
+
+// payeeDisplayName tidies a new payee's name. Bank exports are often all caps, so
+// those become title case; anything else is kept as the user typed it.
+func payeeDisplayName(name string) string {
+	name = strings.TrimSpace(name)
+	if name != strings.ToUpper(name) {
+		return name
+	}
+	words := strings.Fields(normalizePayee(name))
+	for i, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestNormalizePayee(t *testing.T) {
+	cases := map[string]string{
+		"Indomaret":         "indomaret",
+		"INDOMARET 123":     "indomaret",
+		"indomaret pt":      "indomaret",
+		"PT. Indomaret Tbk": "indomaret",
+		"McDonald's":        "mcdonalds",
+		"#4521":             "",
+	}
+	for input, want := range cases {
+		if got := normalizePayee(input); got != want {
+			t.Errorf("normalizePayee(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestMatchPayee(t *testing.T) {
+	payees := []models.Payee{
+		{ID: 1, Name: "Indomaret"},
+		{ID: 2, Name: "McDonald's", Aliases: models.StringList{"MCD"}},
+		{ID: 3, Name: "Gojek", Aliases: models.StringList{"GOFOOD"}},
+	}
+
+	if p := matchPayee(payees, "INDOMARET 123", false); p == nil || p.ID != 1 {
+		t.Errorf("Expected exact match on Indomaret, got %+v", p)
+	}
+	if p := matchPayee(payees, "GOFOOD*MCD KEMANG", false); p != nil {
+		t.Errorf("Expected no exact match for a description, got %+v", p)
+	}
+	if p := matchPayee(payees, "GOFOOD*MCD KEMANG", true); p == nil || p.ID != 3 {
+		t.Errorf("Expected longest partial match Gojek, got %+v", p)
+	}
+	if p := matchPayee(payees, "ALFAMART", true); p != nil {
+		t.Errorf("Expected no match, got %+v", p)
+	}
+}
+
+func TestPayeeDisplayName(t *testing.T) {
+	if got := payeeDisplayName("INDOMARET 123 PT"); got != "Indomaret" {
+		t.Errorf("Expected Indomaret, got %q", got)
+	}
+	if got := payeeDisplayName("Kopi Kenangan"); got != "Kopi Kenangan" {
+		t.Errorf("Expected name kept as typed, got %q", got)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+	"github.com/antigravity/finance-tracker/webpush"
+	"gorm.io/gorm"
+)
+
+var ErrPushEndpoint = errors.New("push endpoint must be an https URL")
+
+type PushService struct {
+	repo   *repositories.PushRepository
+	keys   *webpush.Keys
+	sender *webpush.Sender
+}
+
+// NewPushService loads VAPID keys from VAPID_PUBLIC_KEY/VAPID_PRIVATE_KEY, falling back to
+// a key pair stored in the database, which is generated on first start. VAPID_SUBJECT sets
+// the contact URI sent to push services.
+func NewPushService(repo *repositories.PushRepository) (*PushService, error) {
+	keys, err := loadVapidKeys(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := os.Getenv("VAPID_SUBJECT")
+	if subject == "" {
+		subject = "mailto:admin@localhost"
+	}
+
+	// Endpoints come from browsers, i.e. from users, so they get the same
+	// public-only transport as webhooks and redirects are not followed
+	client := &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: publicTransport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	sender, err := webpush.NewSender(keys, subject, client)
+	if err != nil {
+		return nil, err
+	}
+	return &PushService{repo, keys, sender}, nil
+}
+
+func loadVapidKeys(repo *repositories.PushRepository) (*webpush.Keys, error) {
+	if pub, priv := os.Getenv("VAPID_PUBLIC_KEY"), os.Getenv("VAPID_PRIVATE_KEY"); pub != "" && priv != "" {
+		return &webpush.Keys{PublicKey: pub, PrivateKey: priv}, nil
+	}
+
+	stored, err := repo.FindVapidKey()
+	if err == nil {
+		return &webpush.Keys{PublicKey: stored.PublicKey, PrivateKey: stored.PrivateKey}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	keys, err := webpush.GenerateKeys()
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.CreateVapidKey(&models.VapidKey{PublicKey: keys.PublicKey, PrivateKey: keys.PrivateKey}); err != nil {
+		return nil, err
+	}
+	log.Println("Generated new VAPID key pair")
+	return keys, nil
+}
+
+func (s *PushService) PublicKey() string {
+	return s.keys.PublicKey
+}
+
+func (s *PushService) Subscribe(sub *models.PushSubscription) error {
+	if u, err := url.Parse(sub.Endpoint); err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrPushEndpoint
+	}
+	if _, err := webpush.Encrypt(webpush.Subscription{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}, nil); err != nil {
+		return err
+	}
+	return s.repo.Upsert(sub)
+}
+
+func (s *PushService) GetSubscriptions(userID uint) ([]models.PushSubscription, error) {
+	return s.repo.FindByUser(userID)
+}
+
+func (s *PushService) Unsubscribe(id uint, userID uint) error {
+	return s.repo.Delete(id, userID)
+}
+
+// SendToUser pushes the message to every device of the user, removing
+// subscriptions the push service reports as expired. It returns the number of
+// devices the message was delivered to.
+func (s *PushService) SendToUser(userID uint, message map[string]interface{}) (int, error) {
+	subs, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, sub := range subs {
+		err := s.sender.Send(webpush.Subscription{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}, payload, webpush.Options{})
+		switch {
+		case errors.Is(err, webpush.ErrSubscriptionExpired):
+			if err := s.repo.DeleteByID(sub.ID); err != nil {
+				log.Printf("Warning: Failed to prune push subscription %d: %v", sub.ID, err)
+			}
+		case err != nil:
+			log.Printf("Warning: Failed to push to subscription %d: %v", sub.ID, err)
+		default:
+			delivered++
+			s.repo.TouchLastUsed(sub.ID)
+		}
+	}
+	return delivered, nil
+}
+
+// WebPushNotifier delivers alert notifications to the user's subscribed browsers.
+type WebPushNotifier struct {
+	push *PushService
+}
+
+func NewWebPushNotifier(push *PushService) *WebPushNotifier {
+	return &WebPushNotifier{push}
+}
+
+func (w *WebPushNotifier) Channel() string {
+	return "push"
+}
+
+func (w *WebPushNotifier) Notify(user *models.User, rule *models.AlertRule, n *models.Notification) error {
+	_, err := w.push.SendToUser(user.ID, map[string]interface{}{
+		"id":    n.ID,
+		"type":  n.Type,
+		"title": n.Title,
+		"body":  n.Body,
+		"url":   "/",
+	})
+	return err
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antigravity/finance-tracker/imap"
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/receipts"
+	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReceiptNoUser     = errors.New("no user's receipt address is among the email's recipients")
+	ErrReceiptUnreadable = errors.New("email could not be read")
+)
+
+// receiptTokenBytes gives a 32 character hex token.
+const receiptTokenBytes = 16
+
+// ReceiptService turns emailed receipts into draft transactions for the user to
+// review in the inbox. Mail arrives from an IMAP mailbox or the inbound-mail webhook.
+type ReceiptService struct {
+	repo     *repositories.ReceiptRepository
+	userRepo *repositories.UserRepository
+	trans    *TransactionService
+	audit    *AuditService
+	parsers  *receipts.Registry
+	mailbox  *imap.Config
+	secret   string
+	address  string // Shared mailbox address users add their token to
+}
+
+// ReceiptAddress is where a user emails or forwards receipts. Address is empty
+// when EMAIL_INBOUND_ADDRESS is not set; the token then goes after a "+" in the
+// local part of whatever address reaches the mailbox.
+type ReceiptAddress struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+func NewReceiptService(
+	repo *repositories.ReceiptRepository,
+	userRepo *repositories.UserRepository,
+	trans *TransactionService,
+	audit *AuditService,
+) *ReceiptService {
+	return &ReceiptService{
+		repo:     repo,
+		userRepo: userRepo,
+		trans:    trans,
+		audit:    audit,
+		parsers:  receipts.NewRegistry(),
+		mailbox:  receiptMailboxFromEnv(),
+		secret:   os.Getenv("EMAIL_INBOUND_SECRET"),
+		address:  os.Getenv("EMAIL_INBOUND_ADDRESS"),
+	}
+}
+
+// receiptMailboxFromEnv returns nil unless IMAP_HOST is set. IMAP_TLS=false
+// allows a plain connection, e.g. to a local test server.
+func receiptMailboxFromEnv() *imap.Config {
+	host := os.Getenv("IMAP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := os.Getenv("IMAP_PORT")
+	if port == "" {
+		port = "993"
+	}
+	return &imap.Config{
+		Addr:     net.JoinHostPort(host, port),
+		Username: os.Getenv("IMAP_USER"),
+		Password: os.Getenv("IMAP_PASSWORD"),
+		Mailbox:  os.Getenv("IMAP_MAILBOX"),
+		TLS:      os.Getenv("IMAP_TLS") != "false",
+	}
+}
+
+// Parsers exposes the registry so extra per-sender parsers can be registered.
+func (s *ReceiptService) Parsers() *receipts.Registry {
+	return s.parsers
+}
+
+// InboundSecret is the shared secret the inbound-mail webhook requires, or empty
+// if the webhook is disabled.
+func (s *ReceiptService) InboundSecret() string {
+	return s.secret
+}
+
+// GetAddress returns the user's receipt address, creating it on first use.
+func (s *ReceiptService) GetAddress(userID uint) (*ReceiptAddress, error) {
+	address, err := s.repo.FindAddressByUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.RotateAddress(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.receiptAddress(address.Token), nil
+}
+
+// RotateAddress gives the user a new receipt token; mail to the old one is ignored.
+func (s *ReceiptService) RotateAddress(userID uint) (*ReceiptAddress, error) {
+	buf := make([]byte, receiptTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	address := &models.ReceiptAddress{UserID: userID, Token: hex.EncodeToString(buf)}
+	if err := s.repo.ReplaceAddress(address); err != nil {
+		return nil, err
+	}
+	return s.receiptAddress(address.Token), nil
+}
+
+func (s *ReceiptService) receiptAddress(token string) *ReceiptAddress {
+	result := &ReceiptAddress{Token: token}
+	if local, domain, ok := strings.Cut(s.address, "@"); ok {
+		result.Address = local + "+" + token + "@" + domain
+	}
+	return result
+}
+
+// Ingest reads a raw email and creates a draft expense for the user whose receipt
+// address it was sent to, with the merchant as payee. An email already ingested
+// returns the existing receipt.
+func (s *ReceiptService) Ingest(raw []byte) (*models.EmailReceipt, error) {
+	msg, err := receipts.ReadMessage(raw)
+	if err != nil {
+		return nil, ErrReceiptUnreadable
+	}
+	user := s.findUser(msg)
+	if user == nil {
+		return nil, ErrReceiptNoUser
+	}
+
+	messageID := msg.MessageID
+	if messageID == "" {
+		sum := sha256.Sum256(raw)
+		messageID = hex.EncodeToString(sum[:])
+	}
+	if existing, err := s.repo.FindByMessageID(user.ID, messageID); err == nil {
+		return existing, nil
+	}
+
+	parsed, err := s.parsers.Parse(msg)
+	if err != nil {
+		return nil, err
+	}
+	merchant := truncate(parsed.Merchant, 100)
+	draft := &models.Transaction{
+		UserID:      user.ID,
+		Type:        "expense",
+		Amount:      parsed.Total,
+		Description: merchant,
+		Payee:       merchant,
+		Date:        parsed.Date,
+		Status:      TransactionDraft,
+	}
+	if err := s.trans.Create(draft); err != nil {
+		return nil, err
+	}
+	s.audit.Record(Actor{UserID: user.ID, IP: "email"}, user.ID, AuditCreate, "transaction", draft.ID, nil, draft)
+
+	receipt := &models.EmailReceipt{
+		UserID:        user.ID,
+		MessageID:     messageID,
+		Sender:        msg.From,
+		Subject:       truncate(msg.Subject, 255),
+		Merchant:      merchant,
+		Amount:        parsed.Total,
+		Date:          parsed.Date,
+		Parser:        parsed.Parser,
+		TransactionID: &draft.ID,
+	}
+	if err := s.repo.Create(receipt); err != nil {
+		s.trans.Delete(draft.ID, user.ID)
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// findUser routes the email by the secret token in a recipient's plus address.
+// The sender and any forwarding headers are never trusted for this, since
+// whoever sends the mail controls them.
+func (s *ReceiptService) findUser(msg *receipts.Message) *models.User {
+	for _, recipient := range msg.Recipients {
+		token := receiptToken(recipient)
+		if token == "" {
+			continue
+		}
+		address, err := s.repo.FindAddressByToken(token)
+		if err != nil {
+			continue
+		}
+		if user, err := s.userRepo.FindByID(address.UserID); err == nil {
+			return user
+		}
+	}
+	return nil
+}
+
+// receiptToken returns the plus tag of an address such as receipts+<token>@example.com,
+// or empty if it has none that looks like a token.
+func receiptToken(address string) string {
+	local, _, ok := strings.Cut(strings.ToLower(strings.TrimSpace(address)), "@")
+	if !ok {
+		return ""
+	}
+	_, tag, ok := strings.Cut(local, "+")
+	if !ok || len(tag) != receiptTokenBytes*2 {
+		return ""
+	}
+	if _, err := hex.DecodeString(tag); err != nil {
+		return ""
+	}
+	return tag
+}
+
+// PollMailbox ingests unseen mail from the configured IMAP mailbox. Mail that
+// can never become a receipt is marked seen as well; other failures are retried.
+func (s *ReceiptService) PollMailbox() {
+	if s.mailbox == nil {
+		return
+	}
+	err := imap.FetchUnseen(*s.mailbox, func(raw []byte) error {
+		_, err := s.Ingest(raw)
+		if errors.Is(err, ErrReceiptNoUser) || errors.Is(err, ErrReceiptUnreadable) || errors.Is(err, receipts.ErrNoTotal) {
+			log.Printf("Skipping email: %v", err)
+			return nil
+		}
+		if err != nil {
+			log.Printf("Warning: Failed to ingest email: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to read IMAP mailbox: %v", err)
+	}
+}
+
+func (s *ReceiptService) GetAll(userID uint) ([]models.EmailReceipt, error) {
+	return s.repo.FindAll(userID)
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,22 @@
+package services
+
+import "testing"
+
+func TestReceiptToken(t *testing.T) {
+	token := "0123456789abcdef0123456789abcdef"
+	cases := map[string]string{
+		"receipts+" + token + "@example.com":                    token,
+		"Receipts+0123456789ABCDEF0123456789ABCDEF@Example.com": token,
+		" receipts+" + token + "@example.com ":                  token,
+		"budi@example.com":                                      "",
+		"budi+receipts@example.com":                             "",
+		"receipts+" + token[:30] + "@x.com":                     "",
+		"receipts+" + token[:31] + "z@x.com":                    "",
+		"receipts+" + token:                                     "",
+	}
+	for address, want := range cases {
+		if got := receiptToken(address); got != want {
+			t.Errorf("receiptToken(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
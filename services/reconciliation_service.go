@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	ReconciliationOpen      = "open"
+	ReconciliationCompleted = "completed"
+)
+
+var (
+	ErrReconciliationNotFound   = errors.New("reconciliation not found")
+	ErrReconciliationExists     = errors.New("this account already has an open reconciliation")
+	ErrReconciliationClosed     = errors.New("reconciliation is already completed")
+	ErrReconciliationUnbalanced = errors.New("cleared balance does not match the statement")
+)
+
+// ReconciliationSummary is a session with its running totals and the
+// transactions still to tick off. Difference is what remains unexplained;
+// the session can be completed once it is zero.
+type ReconciliationSummary struct {
+	models.Reconciliation
+	OpeningBalance float64              `json:"opening_balance"`
+	ClearedBalance float64              `json:"cleared_balance"`
+	Difference     float64              `json:"difference"`
+	Transactions   []models.Transaction `json:"transactions"`
+}
+
+type ReconciliationService struct {
+	repo     *repositories.ReconciliationRepository
+	accounts *AccountService
+	audit    *AuditService
+}
+
+func NewReconciliationService(
+	repo *repositories.ReconciliationRepository,
+	accounts *AccountService,
+	audit *AuditService,
+) *ReconciliationService {
+	return &ReconciliationService{repo, accounts, audit}
+}
+
+// Start opens a session for the statement ending on statementDate (a Jakarta
+// calendar day) with the bank's closing balance.
+func (s *ReconciliationService) Start(actor Actor, accountID uint, statementDate time.Time, closingBalance float64) (*ReconciliationSummary, error) {
+	userID := actor.UserID
+	if _, err := s.accounts.GetByID(accountID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindOpen(accountID, userID); err == nil {
+		return nil, ErrReconciliationExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	day := statementDate.In(loc)
+	rec := &models.Reconciliation{
+		UserID:         userID,
+		AccountID:      accountID,
+		StatementDate:  time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc),
+		ClosingBalance: closingBalance,
+		Status:         ReconciliationOpen,
+	}
+	if err := s.repo.Create(rec); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, userID, AuditCreate, "reconciliation", rec.ID, nil, rec)
+	return s.summarize(rec)
+}
+
+func (s *ReconciliationService) Get(id uint, userID uint) (*ReconciliationSummary, error) {
+	rec, err := s.repo.FindByID(id, userID)
+	if err != nil {
+		return nil, ErrReconciliationNotFound
+	}
+	return s.summarize(rec)
+}
+
+func (s *ReconciliationService) GetByAccount(accountID uint, userID uint) ([]models.Reconciliation, error) {
+	if _, err := s.accounts.GetByID(accountID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByAccount(accountID, userID)
+}
+
+// SetCleared ticks transactions on the statement (cleared) or unticks them
+// (pending). Transactions outside the account or already reconciled are left alone.
+func (s *ReconciliationService) SetCleared(actor Actor, id uint, ids []uint, cleared bool) (*ReconciliationSummary, error) {
+	userID := actor.UserID
+	rec, err := s.getOpen(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	from, to := TransactionPending, TransactionCleared
+	if !cleared {
+		from, to = to, from
+	}
+	changed, err := s.repo.SetStatus(ids, rec.AccountID, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	s.recordStatusChanges(actor, changed, to)
+	return s.summarize(rec)
+}
+
+// Complete locks the cleared transactions as reconciled. It refuses while the
+// cleared balance differs from the statement.
+func (s *ReconciliationService) Complete(actor Actor, id uint) (*ReconciliationSummary, int64, error) {
+	userID := actor.UserID
+	rec, err := s.getOpen(id, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	summary, err := s.summarize(rec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if summary.Difference != 0 {
+		return summary, 0, ErrReconciliationUnbalanced
+	}
+
+	before := *rec
+	locked, err := s.repo.Complete(rec, statementEnd(rec))
+	if err != nil {
+		return nil, 0, err
+	}
+	s.recordStatusChanges(actor, locked, TransactionReconciled)
+	s.audit.Record(actor, userID, AuditUpdate, "reconciliation", rec.ID, &before, rec)
+	summary, err = s.summarize(rec)
+	return summary, int64(len(locked)), err
+}
+
+// Cancel discards an open session. Cleared marks stay, since they reflect the bank.
+func (s *ReconciliationService) Cancel(actor Actor, id uint) error {
+	rec, err := s.getOpen(id, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, actor.UserID); err != nil {
+		return err
+	}
+	s.audit.Record(actor, actor.UserID, AuditDelete, "reconciliation", rec.ID, rec, nil)
+	return nil
+}
+
+// recordStatusChanges audits transactions moved to status, given as they were before.
+func (s *ReconciliationService) recordStatusChanges(actor Actor, changed []models.Transaction, status string) {
+	s.audit.RecordTransactionUpdates(actor, changed, func(t *models.Transaction) {
+		t.Status = status
+	})
+}
+
+func (s *ReconciliationService) getOpen(id uint, userID uint) (*models.Reconciliation, error) {
+	rec, err := s.repo.FindByID(id, userID)
+	if err != nil {
+		return nil, ErrReconciliationNotFound
+	}
+	if rec.Status != ReconciliationOpen {
+		return nil, ErrReconciliationClosed
+	}
+	return rec, nil
+}
+
+func (s *ReconciliationService) summarize(rec *models.Reconciliation) (*ReconciliationSummary, error) {
+	account, err := s.accounts.GetByID(rec.AccountID, rec.UserID)
+	if err != nil {
+		return nil, err
+	}
+	end := statementEnd(rec)
+	cleared, err := s.repo.ClearedBalance(rec.AccountID, rec.UserID, end)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReconciliationSummary{
+		Reconciliation: *rec,
+		OpeningBalance: account.OpeningBalance,
+		ClearedBalance: roundCents(account.OpeningBalance + cleared),
+		Difference:     reconciliationDifference(account.OpeningBalance, cleared, rec.ClosingBalance),
+		Transactions:   []models.Transaction{},
+	}
+	if rec.Status == ReconciliationOpen {
+		if summary.Transactions, err = s.repo.FindUnreconciled(rec.AccountID, rec.UserID, end); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+// statementEnd is the start of the day after the statement date, in Jakarta.
+func statementEnd(rec *models.Reconciliation) time.Time {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	return rec.StatementDate.In(loc).AddDate(0, 0, 1)
+}
+
+// reconciliationDifference is the statement's closing balance minus the
+// ledger's cleared balance, rounded to cents so float noise never blocks completion.
+func reconciliationDifference(opening, cleared, closing float64) float64 {
+	return roundCents(closing - (opening + cleared))
+}
+
+func roundCents(v float64) float64 {
+	rounded := math.Round(v*100) / 100
+	if rounded == 0 {
+		return 0 // Avoid -0 in responses
+	}
+	return rounded
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestReconciliationDifference(t *testing.T) {
+	cases := []struct {
+		opening, cleared, closing, want float64
+	}{
+		{1000000, -250000, 750000, 0},
+		{0.1, 0.2, 0.3, 0},
+		{500000, 125000.5, 600000, -25000.5},
+		{0, -150000, 0, 150000},
+	}
+	for _, tc := range cases {
+		got := reconciliationDifference(tc.opening, tc.cleared, tc.closing)
+		if got != tc.want || math.Signbit(got) && got == 0 {
+			t.Errorf("reconciliationDifference(%v, %v, %v) = %v, want %v", tc.opening, tc.cleared, tc.closing, got, tc.want)
+		}
+	}
+}
+
+func TestStatementEndIsNextJakartaDay(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	rec := &models.Reconciliation{StatementDate: time.Date(2024, 3, 31, 0, 0, 0, 0, loc)}
+
+	end := statementEnd(rec)
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, loc); !end.Equal(want) {
+		t.Errorf("Expected the statement to end at %v, got %v", want, end)
+	}
+}
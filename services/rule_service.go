@@ -0,0 +1,361 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	RuleMatchContains = "contains"
+	RuleMatchEquals   = "equals"
+	RuleMatchRegex    = "regex"
+)
+
+// ruleTestLimit caps how many matching transactions the test endpoint returns.
+const ruleTestLimit = 50
+
+type RuleService struct {
+	repo      *repositories.RuleRepository
+	transRepo *repositories.TransactionRepository
+	catRepo   *repositories.CategoryRepository
+	payees    *PayeeService
+	accounts  *AccountService
+	audit     *AuditService
+}
+
+func NewRuleService(
+	repo *repositories.RuleRepository,
+	transRepo *repositories.TransactionRepository,
+	catRepo *repositories.CategoryRepository,
+	payees *PayeeService,
+	accounts *AccountService,
+	audit *AuditService,
+) *RuleService {
+	return &RuleService{repo, transRepo, catRepo, payees, accounts, audit}
+}
+
+// RuleMatch is a transaction a rule matches and what the rule would change it to.
+type RuleMatch struct {
+	Transaction models.Transaction `json:"transaction"`
+	CategoryID  uint               `json:"category_id"`
+	Tags        []string           `json:"tags"`
+	Payee       string             `json:"payee"`
+}
+
+func (s *RuleService) validateRule(rule *models.Rule) error {
+	rule.Tags = normalizeTags(rule.Tags)
+	rule.Payee = strings.TrimSpace(rule.Payee)
+
+	if rule.Pattern == "" && rule.MinAmount == nil && rule.MaxAmount == nil && rule.Type == "" && rule.AccountID == nil {
+		return errors.New("a rule needs at least one condition")
+	}
+	if rule.CategoryID == nil && len(rule.Tags) == 0 && rule.Payee == "" {
+		return errors.New("a rule must set a category, tags or a payee")
+	}
+
+	if rule.Pattern != "" {
+		switch rule.MatchType {
+		case "":
+			rule.MatchType = RuleMatchContains
+		case RuleMatchContains, RuleMatchEquals:
+		case RuleMatchRegex:
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return errors.New("pattern is not a valid regular expression")
+			}
+		default:
+			return errors.New("match_type must be contains, equals or regex")
+		}
+	}
+	if rule.Type != "" && rule.Type != "income" && rule.Type != "expense" {
+		return errors.New("type must be income or expense")
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return errors.New("min_amount cannot be greater than max_amount")
+	}
+
+	if rule.AccountID != nil {
+		if _, err := s.accounts.GetByID(*rule.AccountID, rule.UserID); err != nil {
+			return err
+		}
+	}
+	if rule.CategoryID != nil {
+		cat, err := s.catRepo.FindByID(*rule.CategoryID)
+		if err != nil || (cat.UserID != nil && *cat.UserID != rule.UserID) {
+			return ErrCategoryNotFound
+		}
+		// Without a type condition the rule can hit both income and expenses
+		if cat.Kind != "" && cat.Kind != CategoryKindBoth && rule.Type != cat.Kind {
+			return errors.New("the category only fits " + cat.Kind + " transactions; set the rule type to match")
+		}
+	}
+	return nil
+}
+
+func (s *RuleService) CreateRule(rule *models.Rule) error {
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+	return s.repo.Create(rule)
+}
+
+func (s *RuleService) UpdateRule(rule *models.Rule) error {
+	existing, err := s.repo.FindByID(rule.ID, rule.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+	rule.CreatedAt = existing.CreatedAt
+	return s.repo.Update(rule)
+}
+
+func (s *RuleService) DeleteRule(id uint, userID uint) error {
+	return s.repo.Delete(id, userID)
+}
+
+func (s *RuleService) GetRules(userID uint) ([]models.Rule, error) {
+	return s.repo.FindAll(userID)
+}
+
+// Apply runs the user's rules against a transaction that is about to be created.
+// A category the caller already chose is kept.
+func (s *RuleService) Apply(t *models.Transaction) {
+	rules, err := s.repo.FindEnabled(t.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to load rules for user %d: %v", t.UserID, err)
+		return
+	}
+	set, err := s.newRuleSet(t.UserID, rules)
+	if err != nil {
+		log.Printf("Warning: Failed to load rules for user %d: %v", t.UserID, err)
+		return
+	}
+	applyRules(set, t, 0, false)
+}
+
+// newRuleSet prepares rules for a run over the user's transactions.
+func (s *RuleService) newRuleSet(userID uint, rules []models.Rule) (*ruleSet, error) {
+	categories, err := s.catRepo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	return newRuleSet(rules, categories), nil
+}
+
+// TestRule checks an unsaved rule against the user's existing transactions and
+// returns the ones it would match, newest first.
+func (s *RuleService) TestRule(rule *models.Rule) ([]RuleMatch, int, error) {
+	if err := s.validateRule(rule); err != nil {
+		return nil, 0, err
+	}
+	transactions, err := s.transRepo.FindAll(rule.UserID, map[string]interface{}{})
+	if err != nil {
+		return nil, 0, err
+	}
+	set, err := s.newRuleSet(rule.UserID, []models.Rule{*rule})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matches := []RuleMatch{}
+	total := 0
+	for _, t := range transactions {
+		original := t
+		if applyRules(set, &t, 0, true) == nil {
+			continue
+		}
+		total++
+		if len(matches) < ruleTestLimit {
+			matches = append(matches, RuleMatch{
+				Transaction: original,
+				CategoryID:  t.CategoryID,
+				Tags:        t.Tags,
+				Payee:       t.Payee,
+			})
+		}
+	}
+	return matches, total, nil
+}
+
+// ApplyToExisting runs the user's enabled rules over all their transactions.
+// Transactions in Uncategorized always take a rule's category; others only when
+// overwrite is set. It returns how many transactions matched and how many changed.
+func (s *RuleService) ApplyToExisting(actor Actor, userID uint, overwrite bool) (int, int, error) {
+	rules, err := s.repo.FindEnabled(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	transactions, err := s.transRepo.FindAll(userID, map[string]interface{}{})
+	if err != nil {
+		return 0, 0, err
+	}
+	set, err := s.newRuleSet(userID, rules)
+	if err != nil {
+		return 0, 0, err
+	}
+	var uncategorizedID uint
+	if fallback, err := s.catRepo.FindDefaultByName(UncategorizedCategory); err == nil {
+		uncategorizedID = fallback.ID
+	}
+
+	matched, updated := 0, 0
+	for _, t := range transactions {
+		if t.Status == TransactionReconciled {
+			continue
+		}
+		before := t
+		if applyRules(set, &t, uncategorizedID, overwrite) == nil {
+			continue
+		}
+		matched++
+
+		fields := map[string]interface{}{}
+		if t.CategoryID != before.CategoryID {
+			fields["category_id"] = t.CategoryID
+		}
+		if t.Payee != before.Payee {
+			payee, err := s.payees.Resolve(userID, t.Payee)
+			if err != nil {
+				return matched, updated, err
+			}
+			fields["payee"] = t.Payee
+			if payee != nil {
+				fields["payee"] = payee.Name
+				fields["payee_id"] = payee.ID
+			}
+		}
+		if strings.Join(t.Tags, ",") != strings.Join(before.Tags, ",") {
+			fields["tags"] = t.Tags
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		ok, err := s.transRepo.UpdateFields(t.ID, userID, fields, before.Version)
+		if err != nil {
+			return matched, updated, err
+		}
+		if !ok {
+			// Changed by someone else since we read it; leave it for the next run
+			continue
+		}
+		updated++
+		if after, err := s.transRepo.FindByID(t.ID, userID); err == nil {
+			s.audit.Record(actor, userID, AuditUpdate, "transaction", t.ID, &before, after)
+		}
+	}
+	return matched, updated, nil
+}
+
+// ruleSet is rules prepared for one run: regex patterns are compiled once, and
+// the categories rules may still assign are known.
+type ruleSet struct {
+	rules      []models.Rule
+	patterns   []*regexp.Regexp
+	categories map[uint]models.Category
+}
+
+// newRuleSet prepares rules, which may assign only the given categories.
+func newRuleSet(rules []models.Rule, categories []models.Category) *ruleSet {
+	set := &ruleSet{rules: rules, patterns: make([]*regexp.Regexp, len(rules)), categories: make(map[uint]models.Category, len(categories))}
+	for i := range rules {
+		set.patterns[i] = compileRule(rules[i])
+	}
+	for _, c := range categories {
+		set.categories[c.ID] = c
+	}
+	return set
+}
+
+// compileRule compiles a regex rule's pattern, or returns nil for other rules
+// and invalid patterns.
+func compileRule(rule models.Rule) *regexp.Regexp {
+	if rule.MatchType != RuleMatchRegex || rule.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile("(?i)" + rule.Pattern)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// ruleMatches reports whether every condition set on the rule holds for t.
+// Description matching ignores case; re is the rule's compiled regex, if any.
+func ruleMatches(rule models.Rule, re *regexp.Regexp, t *models.Transaction) bool {
+	if rule.Type != "" && rule.Type != t.Type {
+		return false
+	}
+	if rule.MinAmount != nil && t.Amount < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && t.Amount > *rule.MaxAmount {
+		return false
+	}
+	if rule.AccountID != nil && (t.AccountID == nil || *t.AccountID != *rule.AccountID) {
+		return false
+	}
+	if rule.Pattern == "" {
+		return true
+	}
+
+	description := strings.ToLower(strings.TrimSpace(t.Description))
+	pattern := strings.ToLower(strings.TrimSpace(rule.Pattern))
+	switch rule.MatchType {
+	case RuleMatchEquals:
+		return description == pattern
+	case RuleMatchRegex:
+		return re != nil && re.MatchString(t.Description)
+	default:
+		return strings.Contains(description, pattern)
+	}
+}
+
+// applyRules applies the first matching rule to t and returns it, or nil if none
+// matched. The rule's category is used when t has none (0 or uncategorizedID) or
+// overwrite is set, as long as it still exists and fits t's type; its tags are
+// added and its payee fills an empty payee.
+func applyRules(set *ruleSet, t *models.Transaction, uncategorizedID uint, overwrite bool) *models.Rule {
+	for i := range set.rules {
+		rule := &set.rules[i]
+		if !ruleMatches(*rule, set.patterns[i], t) {
+			continue
+		}
+
+		uncategorized := t.CategoryID == 0 || (uncategorizedID != 0 && t.CategoryID == uncategorizedID)
+		if rule.CategoryID != nil && (uncategorized || overwrite) {
+			if category, ok := set.categories[*rule.CategoryID]; ok && kindAllows(category.Kind, t.Type) {
+				t.CategoryID = *rule.CategoryID
+			}
+		}
+		if len(rule.Tags) > 0 {
+			t.Tags = normalizeTags(append(append([]string{}, t.Tags...), rule.Tags...))
+		}
+		if rule.Payee != "" && (t.Payee == "" || overwrite) {
+			t.Payee = rule.Payee
+		}
+		return rule
+	}
+	return nil
+}
+
+// normalizeTags trims and lowercases tags, dropping blanks and duplicates.
+func normalizeTags(tags []string) models.StringList {
+	normalized := models.StringList{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestRuleMatches(t *testing.T) {
+	min, max := 10000.0, 100000.0
+	card, cash := uint(3), uint(4)
+	cases := []struct {
+		name string
+		rule models.Rule
+		want bool
+	}{
+		{"contains ignores case", models.Rule{MatchType: RuleMatchContains, Pattern: "gofood"}, true},
+		{"equals needs whole description", models.Rule{MatchType: RuleMatchEquals, Pattern: "gofood"}, false},
+		{"regex", models.Rule{MatchType: RuleMatchRegex, Pattern: `^GOFOOD\*(MCD|KFC)`}, true},
+		{"amount in range", models.Rule{MinAmount: &min, MaxAmount: &max}, true},
+		{"amount below min", models.Rule{MinAmount: &max}, false},
+		{"type mismatch", models.Rule{Type: "income", Pattern: "gofood"}, false},
+		{"account matches", models.Rule{AccountID: &card, Pattern: "gofood"}, true},
+		{"account mismatch", models.Rule{AccountID: &cash, Pattern: "gofood"}, false},
+	}
+
+	tx := &models.Transaction{Type: "expense", Amount: 56000, Description: "GOFOOD*MCD KEMANG", AccountID: &card}
+	for _, tc := range cases {
+		if got := ruleMatches(tc.rule, compileRule(tc.rule), tx); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestApplyRulesFirstMatchWins(t *testing.T) {
+	food, fastFood := uint(1), uint(2)
+	rules := []models.Rule{
+		{ID: 10, Pattern: "mcd", CategoryID: &fastFood, Tags: models.StringList{"Fast Food"}, Payee: "McDonald's"},
+		{ID: 11, Pattern: "gofood", CategoryID: &food, Tags: models.StringList{"delivery"}},
+	}
+
+	tx := &models.Transaction{Type: "expense", Description: "GOFOOD*MCD KEMANG", Tags: models.StringList{"lunch"}}
+	matched := applyRules(newRuleSet(rules, []models.Category{{ID: food}, {ID: fastFood}}), tx, 0, false)
+	if matched == nil || matched.ID != 10 {
+		t.Fatalf("Expected rule 10 to match, got %+v", matched)
+	}
+	if tx.CategoryID != fastFood {
+		t.Errorf("Expected category %d, got %d", fastFood, tx.CategoryID)
+	}
+	if !reflect.DeepEqual([]string(tx.Tags), []string{"lunch", "fast food"}) {
+		t.Errorf("Expected tags [lunch fast food], got %v", tx.Tags)
+	}
+	if tx.Payee != "McDonald's" {
+		t.Errorf("Expected payee McDonald's, got %q", tx.Payee)
+	}
+}
+
+func TestApplyRulesKeepsChosenCategory(t *testing.T) {
+	food := uint(1)
+	set := newRuleSet([]models.Rule{{Pattern: "gofood", CategoryID: &food}}, []models.Category{{ID: food}})
+
+	tx := &models.Transaction{Description: "GOFOOD", CategoryID: 7}
+	applyRules(set, tx, 99, false)
+	if tx.CategoryID != 7 {
+		t.Errorf("Expected chosen category 7 to be kept, got %d", tx.CategoryID)
+	}
+
+	tx = &models.Transaction{Description: "GOFOOD", CategoryID: 99}
+	applyRules(set, tx, 99, false)
+	if tx.CategoryID != food {
+		t.Errorf("Expected Uncategorized to be replaced with %d, got %d", food, tx.CategoryID)
+	}
+
+	tx = &models.Transaction{Description: "GOFOOD", CategoryID: 7}
+	applyRules(set, tx, 99, true)
+	if tx.CategoryID != food {
+		t.Errorf("Expected overwrite to set %d, got %d", food, tx.CategoryID)
+	}
+}
+
+func TestApplyRulesSkipsUnusableCategory(t *testing.T) {
+	deleted, income := uint(1), uint(2)
+	categories := []models.Category{{ID: income, Kind: CategoryKindIncome}}
+	for _, id := range []uint{deleted, income} {
+		set := newRuleSet([]models.Rule{{Pattern: "gofood", CategoryID: &id}}, categories)
+		tx := &models.Transaction{Type: "expense", Description: "GOFOOD", CategoryID: 99}
+		applyRules(set, tx, 99, false)
+		if tx.CategoryID != 99 {
+			t.Errorf("Expected category %d not to be assigned, got %d", id, tx.CategoryID)
+		}
+	}
+}
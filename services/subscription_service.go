@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	// subscriptionLookbackDays covers a year of history plus a yearly charge's drift.
+	subscriptionLookbackDays = 400
+	// subscriptionAmountSpread is how far a charge may stray from the typical
+	// amount and still count, as a share of it.
+	subscriptionAmountSpread = 0.15
+	// subscriptionRegularShare is the share of gaps and amounts that must fit,
+	// leaving room for one skipped month or a price change.
+	subscriptionRegularShare = 0.75
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrSubscriptionTracked  = errors.New("subscription is already tracked as a bill")
+	ErrSubscriptionWeekly   = errors.New("weekly charges cannot be tracked as a bill")
+)
+
+// cadence is a billing interval: months for calendar stepping (0 for weekly) and
+// the range of gaps in days that count as it.
+type cadence struct {
+	Name    string
+	Months  int
+	Days    int
+	MinDays float64
+	MaxDays float64
+}
+
+var cadences = []cadence{
+	{Name: "weekly", Days: 7, MinDays: 6, MaxDays: 8},
+	{Name: "monthly", Months: 1, MinDays: 26, MaxDays: 35},
+	{Name: "quarterly", Months: 3, MinDays: 84, MaxDays: 98},
+	{Name: "yearly", Months: 12, MinDays: 350, MaxDays: 380},
+}
+
+// Subscription is a recurring charge found in the user's history. Key identifies
+// it for confirmation; BillID is set once it is tracked as a bill.
+type Subscription struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	PayeeID     *uint     `json:"payee_id"`
+	Cadence     string    `json:"cadence"` // weekly, monthly, quarterly or yearly
+	Amount      float64   `json:"amount"`  // Latest charge
+	MonthlyCost float64   `json:"monthly_cost"`
+	AnnualCost  float64   `json:"annual_cost"`
+	Charges     int       `json:"charges"`
+	LastDate    time.Time `json:"last_date"`
+	NextDate    time.Time `json:"next_date"`
+	CategoryID  uint      `json:"category_id"`
+	AccountID   *uint     `json:"account_id"`
+	BillID      *uint     `json:"bill_id"`
+}
+
+type SubscriptionService struct {
+	transRepo *repositories.TransactionRepository
+	billRepo  *repositories.BillRepository
+	bills     *BillService
+	payees    *PayeeService
+}
+
+func NewSubscriptionService(
+	transRepo *repositories.TransactionRepository,
+	billRepo *repositories.BillRepository,
+	bills *BillService,
+	payees *PayeeService,
+) *SubscriptionService {
+	return &SubscriptionService{transRepo, billRepo, bills, payees}
+}
+
+// Detect finds the user's active recurring charges, costliest first.
+func (s *SubscriptionService) Detect(userID uint) ([]Subscription, error) {
+	today := jakartaToday()
+	expenses, err := s.transRepo.FindAll(userID, map[string]interface{}{
+		"type":       "expense",
+		"start_date": today.AddDate(0, 0, -subscriptionLookbackDays),
+	})
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := detectSubscriptions(expenses, today)
+
+	bills, err := s.billRepo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	payees, err := s.payees.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].BillID = trackingBill(&subscriptions[i], bills, payees)
+	}
+	return subscriptions, nil
+}
+
+// trackingBill returns the ID of the bill that tracks sub, if any. Groups keyed
+// by name have no payee of their own, so their name is matched to a payee the
+// way Confirm's bill resolves it, or else to the bill's payee text.
+func trackingBill(sub *Subscription, bills []models.Bill, payees []models.Payee) *uint {
+	payeeID := sub.PayeeID
+	if payeeID == nil {
+		if payee := matchPayee(payees, sub.Name, false); payee != nil {
+			payeeID = &payee.ID
+		}
+	}
+	name := normalizePayee(sub.Name)
+	for j := range bills {
+		if payeeID != nil && bills[j].PayeeID != nil && *bills[j].PayeeID == *payeeID {
+			return &bills[j].ID
+		}
+		if sub.PayeeID == nil && name != "" && normalizePayee(bills[j].Payee) == name {
+			return &bills[j].ID
+		}
+	}
+	return nil
+}
+
+// Confirm tracks a detected subscription as a bill due on the day of its last
+// charge. name overrides the detected name when set.
+func (s *SubscriptionService) Confirm(userID uint, key string, name string) (*models.Bill, error) {
+	subscriptions, err := s.Detect(userID)
+	if err != nil {
+		return nil, err
+	}
+	var found *Subscription
+	for i := range subscriptions {
+		if subscriptions[i].Key == key {
+			found = &subscriptions[i]
+			break
+		}
+	}
+	switch {
+	case found == nil:
+		return nil, ErrSubscriptionNotFound
+	case found.BillID != nil:
+		return nil, ErrSubscriptionTracked
+	case found.Cadence == "weekly":
+		return nil, ErrSubscriptionWeekly
+	}
+
+	months := 1
+	for _, c := range cadences {
+		if c.Name == found.Cadence {
+			months = c.Months
+		}
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		name = found.Name
+	}
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	lastCharge := found.LastDate.In(loc)
+	bill := &models.Bill{
+		UserID:           userID,
+		Name:             name,
+		Amount:           found.Amount,
+		DueDay:           lastCharge.Day(),
+		IntervalMonths:   months,
+		StartDate:        lastCharge,
+		Payee:            found.Name,
+		CategoryID:       &found.CategoryID,
+		AccountID:        found.AccountID,
+		RemindDaysBefore: 3,
+		Active:           true,
+	}
+	if err := s.bills.Create(bill); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// detectSubscriptions groups expenses by payee, or by their text when there is
+// none, and keeps the groups charged at a regular cadence for a steady amount
+// that are still running as of today.
+func detectSubscriptions(expenses []models.Transaction, today time.Time) []Subscription {
+	groups := map[string][]models.Transaction{}
+	var keys []string
+	for _, t := range expenses {
+		key := subscriptionKey(&t)
+		if key == "" {
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	subscriptions := []Subscription{}
+	for _, key := range keys {
+		charges := groups[key]
+		sort.SliceStable(charges, func(i, j int) bool { return charges[i].Date.Before(charges[j].Date) })
+		if sub, ok := recurringCharge(key, charges, today); ok {
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool { return subscriptions[i].AnnualCost > subscriptions[j].AnnualCost })
+	return subscriptions
+}
+
+func subscriptionKey(t *models.Transaction) string {
+	if t.PayeeID != nil {
+		return fmt.Sprintf("payee:%d", *t.PayeeID)
+	}
+	name := t.Payee
+	if name == "" {
+		name = t.Description
+	}
+	if normalized := normalizePayee(name); normalized != "" {
+		return "name:" + normalized
+	}
+	return ""
+}
+
+// recurringCharge decides whether charges, oldest first, form a subscription.
+func recurringCharge(key string, charges []models.Transaction, today time.Time) (Subscription, bool) {
+	if len(charges) < 2 {
+		return Subscription{}, false
+	}
+	gaps := make([]float64, 0, len(charges)-1)
+	for i := 1; i < len(charges); i++ {
+		gaps = append(gaps, charges[i].Date.Sub(charges[i-1].Date).Hours()/24)
+	}
+	typical := median(gaps)
+
+	var found *cadence
+	for i := range cadences {
+		if typical >= cadences[i].MinDays && typical <= cadences[i].MaxDays {
+			found = &cadences[i]
+		}
+	}
+	// A single gap is only convincing for yearly charges
+	if found == nil || (len(gaps) < 2 && found.Months != 12) {
+		return Subscription{}, false
+	}
+	if share(gaps, func(g float64) bool { return g >= found.MinDays && g <= found.MaxDays }) < subscriptionRegularShare {
+		return Subscription{}, false
+	}
+
+	amounts := make([]float64, len(charges))
+	for i, t := range charges {
+		amounts[i] = t.Amount
+	}
+	usual := median(amounts)
+	if share(amounts, func(a float64) bool { return math.Abs(a-usual) <= usual*subscriptionAmountSpread }) < subscriptionRegularShare {
+		return Subscription{}, false
+	}
+
+	last := charges[len(charges)-1]
+	// Stopped subscriptions are not worth flagging
+	if today.Sub(last.Date).Hours()/24 > found.MaxDays*1.5 {
+		return Subscription{}, false
+	}
+
+	sub := Subscription{
+		Key:        key,
+		Name:       last.Payee,
+		PayeeID:    last.PayeeID,
+		Cadence:    found.Name,
+		Amount:     last.Amount,
+		Charges:    len(charges),
+		LastDate:   last.Date,
+		CategoryID: last.CategoryID,
+		AccountID:  last.AccountID,
+	}
+	if sub.Name == "" {
+		sub.Name = last.Description
+	}
+	chargesPerYear := 52.0
+	if found.Months > 0 {
+		sub.NextDate = last.Date.AddDate(0, found.Months, 0)
+		chargesPerYear = 12 / float64(found.Months)
+	} else {
+		sub.NextDate = last.Date.AddDate(0, 0, found.Days)
+	}
+	sub.AnnualCost = roundCents(last.Amount * chargesPerYear)
+	sub.MonthlyCost = roundCents(last.Amount * chargesPerYear / 12)
+	return sub, true
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func share(values []float64, fits func(float64) bool) float64 {
+	n := 0
+	for _, v := range values {
+		if fits(v) {
+			n++
+		}
+	}
+	return float64(n) / float64(len(values))
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func charges(payeeID uint, amount float64, dates ...time.Time) []models.Transaction {
+	var transactions []models.Transaction
+	for i, date := range dates {
+		transactions = append(transactions, models.Transaction{
+			ID: payeeID*100 + uint(i), Type: "expense", Amount: amount, PayeeID: &payeeID, Payee: "Payee", Date: date,
+		})
+	}
+	return transactions
+}
+
+func TestDetectSubscriptions(t *testing.T) {
+	today := jakartaDate(2024, 6, 20)
+	var expenses []models.Transaction
+	// Netflix on the 5th, with one month skipped and a price rise
+	netflix := charges(1, 186000, jakartaDate(2024, 1, 5), jakartaDate(2024, 2, 5), jakartaDate(2024, 4, 5), jakartaDate(2024, 5, 6))
+	netflix = append(netflix, charges(1, 199000, jakartaDate(2024, 6, 5))...)
+	netflix[len(netflix)-1].ID = 199
+	expenses = append(expenses, netflix...)
+	// Domain renewed yearly
+	expenses = append(expenses, charges(2, 250000, jakartaDate(2023, 6, 20), jakartaDate(2024, 6, 18))...)
+	// Groceries: regular-ish but amounts all over the place
+	expenses = append(expenses, charges(3, 0, jakartaDate(2024, 3, 1), jakartaDate(2024, 4, 1), jakartaDate(2024, 5, 1), jakartaDate(2024, 6, 1))...)
+	for i, amount := range []float64{120000, 450000, 90000, 300000} {
+		expenses[len(expenses)-4+i].Amount = amount
+	}
+	// Gym that was cancelled in January
+	expenses = append(expenses, charges(4, 300000, jakartaDate(2023, 10, 10), jakartaDate(2023, 11, 10), jakartaDate(2023, 12, 10))...)
+
+	subs := detectSubscriptions(expenses, today)
+	if len(subs) != 2 {
+		t.Fatalf("Expected Netflix and the domain, got %+v", subs)
+	}
+	netflixSub := subs[0]
+	if netflixSub.Key != "payee:1" || netflixSub.Cadence != "monthly" || netflixSub.Amount != 199000 || netflixSub.AnnualCost != 2388000 {
+		t.Errorf("Unexpected monthly subscription: %+v", netflixSub)
+	}
+	if !netflixSub.NextDate.Equal(jakartaDate(2024, 7, 5)) {
+		t.Errorf("Expected the next charge on 5 Jul, got %v", netflixSub.NextDate)
+	}
+	if domain := subs[1]; domain.Cadence != "yearly" || domain.MonthlyCost != 20833.33 {
+		t.Errorf("Unexpected yearly subscription: %+v", domain)
+	}
+}
+
+func TestTrackingBill(t *testing.T) {
+	netflixID, gymID := uint(1), uint(2)
+	payees := []models.Payee{
+		{ID: netflixID, Name: "Netflix"},
+		{ID: gymID, Name: "Gym Club", Aliases: models.StringList{"gym club sudirman"}},
+	}
+	bills := []models.Bill{
+		{ID: 10, Payee: "Netflix", PayeeID: &netflixID},
+		{ID: 11, Payee: "Gym Club", PayeeID: &gymID},
+		{ID: 12, Payee: "Kos Bu Rina"},
+	}
+	cases := []struct {
+		sub  Subscription
+		want uint
+	}{
+		{Subscription{Key: "payee:1", Name: "Netflix", PayeeID: &netflixID}, 10},
+		{Subscription{Key: "name:gym club sudirman", Name: "GYM CLUB SUDIRMAN"}, 11},
+		{Subscription{Key: "name:kos bu rina", Name: "kos bu rina"}, 12},
+		{Subscription{Key: "name:spotify", Name: "Spotify"}, 0},
+	}
+	for _, tc := range cases {
+		got := trackingBill(&tc.sub, bills, payees)
+		if (got == nil) != (tc.want == 0) || (got != nil && *got != tc.want) {
+			t.Errorf("%s: got bill %v, want %d", tc.sub.Key, got, tc.want)
+		}
+	}
+}
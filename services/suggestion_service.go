@@ -0,0 +1,148 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/antigravity/finance-tracker/classifier"
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+)
+
+const (
+	// suggestionModelTTL bounds how stale a user's model can get from changes it did
+	// not see, such as bulk rule runs, syncs or writes handled by another instance.
+	suggestionModelTTL = time.Hour
+	suggestionLimit    = 3
+)
+
+// CategorySuggestion is a suggested category with the model's confidence in it (0-1).
+type CategorySuggestion struct {
+	CategoryID   uint    `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Confidence   float64 `json:"confidence"`
+}
+
+type userModel struct {
+	mu        sync.Mutex
+	nb        *classifier.NaiveBayes
+	trainedAt time.Time
+}
+
+// SuggestionService keeps a naive Bayes model per user, trained lazily from their
+// categorized transactions and updated as transactions change.
+type SuggestionService struct {
+	transRepo *repositories.TransactionRepository
+	catRepo   *repositories.CategoryRepository
+
+	mu              sync.Mutex
+	models          map[uint]*userModel
+	uncategorizedID uint
+}
+
+func NewSuggestionService(transRepo *repositories.TransactionRepository, catRepo *repositories.CategoryRepository) *SuggestionService {
+	return &SuggestionService{transRepo: transRepo, catRepo: catRepo, models: make(map[uint]*userModel)}
+}
+
+// Suggest ranks categories for a new transaction. txType, when set, limits the
+// candidates to categories of a matching kind. Archived and hidden categories are
+// never suggested.
+func (s *SuggestionService) Suggest(userID uint, description string, amount float64, txType string) ([]CategorySuggestion, error) {
+	model, err := s.model(userID)
+	if err != nil {
+		return nil, err
+	}
+	model.mu.Lock()
+	predictions := model.nb.Predict(classifier.Features(description, amount))
+	model.mu.Unlock()
+
+	categories, err := s.catRepo.FindVisible(userID, false, false, txType)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	suggestions := []CategorySuggestion{}
+	for _, p := range predictions {
+		name, ok := names[p.Label]
+		if !ok {
+			continue
+		}
+		suggestions = append(suggestions, CategorySuggestion{CategoryID: p.Label, CategoryName: name, Confidence: p.Probability})
+		if len(suggestions) == suggestionLimit {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// Observe updates a trained model for a transaction change: before is nil for a
+// create and after is nil for a delete. Users without a model in memory are
+// skipped; they get trained from scratch on their next suggestion.
+func (s *SuggestionService) Observe(before, after *models.Transaction) {
+	userID := uint(0)
+	if before != nil {
+		userID = before.UserID
+	} else if after != nil {
+		userID = after.UserID
+	}
+
+	s.mu.Lock()
+	model, ok := s.models[userID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	uncategorizedID := s.uncategorized()
+	model.mu.Lock()
+	defer model.mu.Unlock()
+	if before != nil && before.CategoryID != uncategorizedID {
+		model.nb.Unlearn(before.CategoryID, classifier.Features(before.Description, before.Amount))
+	}
+	if after != nil && after.CategoryID != uncategorizedID {
+		model.nb.Learn(after.CategoryID, classifier.Features(after.Description, after.Amount))
+	}
+}
+
+func (s *SuggestionService) model(userID uint) (*userModel, error) {
+	s.mu.Lock()
+	model, ok := s.models[userID]
+	s.mu.Unlock()
+	if ok && time.Since(model.trainedAt) < suggestionModelTTL {
+		return model, nil
+	}
+
+	transactions, err := s.transRepo.FindAll(userID, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	uncategorizedID := s.uncategorized()
+	model = &userModel{nb: classifier.New(), trainedAt: time.Now()}
+	for _, t := range transactions {
+		// Uncategorized says nothing about where a transaction belongs
+		if t.CategoryID == uncategorizedID {
+			continue
+		}
+		model.nb.Learn(t.CategoryID, classifier.Features(t.Description, t.Amount))
+	}
+
+	s.mu.Lock()
+	s.models[userID] = model
+	s.mu.Unlock()
+	return model, nil
+}
+
+func (s *SuggestionService) uncategorized() uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.uncategorizedID == 0 {
+		if fallback, err := s.catRepo.FindDefaultByName(UncategorizedCategory); err == nil {
+			s.uncategorizedID = fallback.ID
+		}
+	}
+	return s.uncategorizedID
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+	"github.com/antigravity/finance-tracker/utils"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// syncCursorLag holds the cursor back from the present. A write can commit after
+// a sync has read past its updated_at, and app instances' clocks differ slightly;
+// changes inside the lag are sent again on the next sync instead of being missed.
+const syncCursorLag = 2 * time.Minute
+
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+type SyncService struct {
+	repo       *repositories.SyncRepository
+	catRepo    *repositories.CategoryRepository
+	categories *CategoryService
+	trans      *TransactionService
+	audit      *AuditService
+}
+
+func NewSyncService(
+	repo *repositories.SyncRepository,
+	catRepo *repositories.CategoryRepository,
+	categories *CategoryService,
+	trans *TransactionService,
+	audit *AuditService,
+) *SyncService {
+	return &SyncService{repo, catRepo, categories, trans, audit}
+}
+
+// SyncMutation is one offline change. BaseVersion is the version the client
+// last saw, or 0 for rows created on the client.
+type SyncMutation struct {
+	Entity      string   `json:"entity"` // transaction or category
+	Op          string   `json:"op"`     // upsert or delete
+	UUID        string   `json:"uuid"`
+	BaseVersion uint     `json:"base_version"`
+	Data        SyncData `json:"data"`
+}
+
+type SyncData struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Amount       float64   `json:"amount"`
+	CategoryID   uint      `json:"category_id"`
+	CategoryUUID string    `json:"category_uuid"`
+	Description  string    `json:"description"`
+	Date         time.Time `json:"date"`
+}
+
+type SyncResult struct {
+	Entity  string      `json:"entity"`
+	UUID    string      `json:"uuid"`
+	Status  string      `json:"status"`
+	Version uint        `json:"version,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Server  interface{} `json:"server,omitempty"` // Current server row when the mutation lost a conflict
+}
+
+type SyncCategory struct {
+	models.Category
+	Deleted bool `json:"deleted"`
+}
+
+type SyncTransaction struct {
+	models.Transaction
+	CategoryUUID string `json:"category_uuid"`
+	Deleted      bool   `json:"deleted"`
+}
+
+type SyncResponse struct {
+	Results []SyncResult `json:"results"`
+	Changes struct {
+		Categories   []SyncCategory    `json:"categories"`
+		Transactions []SyncTransaction `json:"transactions"`
+	} `json:"changes"`
+	Cursor string `json:"cursor"`
+}
+
+// Sync applies the client's mutations and returns every server change after cursor.
+//
+// Conflicts are resolved by version: a mutation is applied only when its base
+// version equals the row's current version, so the first writer wins and later
+// writers get the server row back to rebase on. Categories are applied before
+// transactions so offline-created categories can be referenced by UUID.
+func (s *SyncService) Sync(actor Actor, cursor string, mutations []SyncMutation) (*SyncResponse, error) {
+	userID := actor.UserID
+	since, err := parseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	now, err := s.repo.Now()
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]SyncMutation, len(mutations))
+	copy(ordered, mutations)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Entity == "category" && ordered[j].Entity != "category"
+	})
+
+	resp := &SyncResponse{Results: make([]SyncResult, 0, len(ordered))}
+	for _, m := range ordered {
+		var result SyncResult
+		var err error
+		switch m.Entity {
+		case "category":
+			result, err = s.applyCategory(actor, m)
+		case "transaction":
+			result, err = s.applyTransaction(actor, m)
+		default:
+			result = SyncResult{Status: SyncRejected, Error: "unknown entity"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Entity = m.Entity
+		result.UUID = m.UUID
+		resp.Results = append(resp.Results, result)
+	}
+
+	categories, err := s.repo.CategoriesChangedSince(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.repo.TransactionsChangedSince(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	tombstones, err := s.repo.TombstonesSince(userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := since
+	track := func(updatedAt time.Time, deletedAt gorm.DeletedAt) {
+		if updatedAt.After(latest) {
+			latest = updatedAt
+		}
+		if deletedAt.Valid && deletedAt.Time.After(latest) {
+			latest = deletedAt.Time
+		}
+	}
+
+	resp.Changes.Categories = make([]SyncCategory, 0, len(categories))
+	for _, c := range categories {
+		track(c.UpdatedAt, c.DeletedAt)
+		resp.Changes.Categories = append(resp.Changes.Categories, SyncCategory{c, c.DeletedAt.Valid})
+	}
+	resp.Changes.Transactions = make([]SyncTransaction, 0, len(transactions))
+	for _, t := range transactions {
+		track(t.UpdatedAt, t.DeletedAt)
+		t.CategoryName = t.Category.Name
+		resp.Changes.Transactions = append(resp.Changes.Transactions, SyncTransaction{t, t.Category.UUID, t.DeletedAt.Valid})
+	}
+	// Rows purged from the trash only have their uuid left
+	for _, ts := range tombstones {
+		deletedAt := gorm.DeletedAt{Time: ts.DeletedAt, Valid: true}
+		track(ts.DeletedAt, deletedAt)
+		switch ts.Entity {
+		case "category":
+			resp.Changes.Categories = append(resp.Changes.Categories,
+				SyncCategory{models.Category{UserID: &ts.UserID, UUID: ts.UUID, DeletedAt: deletedAt}, true})
+		case "transaction":
+			resp.Changes.Transactions = append(resp.Changes.Transactions,
+				SyncTransaction{models.Transaction{UserID: ts.UserID, UUID: ts.UUID, DeletedAt: deletedAt}, "", true})
+		}
+	}
+
+	// Clients apply changes by version, so ones sent again within the lag are harmless
+	if cutoff := now.Add(-syncCursorLag); latest.After(cutoff) {
+		latest = cutoff
+		if since.After(latest) {
+			latest = since
+		}
+	}
+	resp.Cursor = formatCursor(latest)
+	return resp, nil
+}
+
+func (s *SyncService) applyCategory(actor Actor, m SyncMutation) (SyncResult, error) {
+	userID := actor.UserID
+	if !utils.IsUUID(m.UUID) {
+		return SyncResult{Status: SyncRejected, Error: "uuid must be a valid UUID"}, nil
+	}
+
+	existing, err := s.repo.FindCategoryByUUID(userID, m.UUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if m.Op == "delete" {
+			return SyncResult{Status: SyncApplied}, nil
+		}
+		name, err := s.categories.validateName(userID, m.Data.Name, 0)
+		if errors.Is(err, ErrCategoryNameEmpty) || errors.Is(err, ErrCategoryNameTaken) {
+			return SyncResult{Status: SyncRejected, Error: err.Error()}, nil
+		} else if err != nil {
+			return SyncResult{}, err
+		}
+		c := &models.Category{UserID: &userID, Name: name, UUID: m.UUID}
+		created, err := s.repo.CreateCategory(c)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if !created {
+			// Another request created it first
+			current, err := s.repo.FindCategoryByUUID(userID, m.UUID)
+			if err != nil {
+				return SyncResult{}, err
+			}
+			return s.categoryConflict(current), nil
+		}
+		s.audit.Record(actor, userID, AuditCreate, "category", c.ID, nil, c)
+		return SyncResult{Status: SyncApplied, Version: c.Version}, nil
+	}
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	if existing.UserID == nil {
+		return SyncResult{Status: SyncRejected, Error: "default categories cannot be modified"}, nil
+	}
+	if m.Op == "delete" && existing.DeletedAt.Valid {
+		// Already gone, e.g. deleted on another device
+		return SyncResult{Status: SyncApplied, Version: existing.Version}, nil
+	}
+	if m.BaseVersion != existing.Version {
+		return s.categoryConflict(existing), nil
+	}
+	name := existing.Name
+	if m.Op != "delete" {
+		name, err = s.categories.validateName(userID, m.Data.Name, existing.ID)
+		if errors.Is(err, ErrCategoryNameEmpty) || errors.Is(err, ErrCategoryNameTaken) {
+			return SyncResult{Status: SyncRejected, Version: existing.Version, Error: err.Error()}, nil
+		} else if err != nil {
+			return SyncResult{}, err
+		}
+	}
+
+	before := *existing
+	if m.Op == "delete" {
+		// Deleting offline cannot pick a replacement, so anything still using the
+		// category falls back to Uncategorized.
+		fallback, err := s.catRepo.FindDefaultByName(UncategorizedCategory)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		moved, err := s.catRepo.DeleteAndReassignIfVersion(existing.ID, userID, fallback.ID, m.BaseVersion)
+		if errors.Is(err, repositories.ErrVersionChanged) {
+			current, err := s.repo.FindCategoryByUUID(userID, m.UUID)
+			if err != nil {
+				return SyncResult{}, err
+			}
+			return s.categoryConflict(current), nil
+		}
+		if err != nil {
+			return SyncResult{}, err
+		}
+		s.audit.RecordTransactionUpdates(actor, moved, func(t *models.Transaction) {
+			t.CategoryID = fallback.ID
+		})
+		s.audit.Record(actor, userID, AuditDelete, "category", existing.ID, before, nil)
+		return SyncResult{Status: SyncApplied, Version: m.BaseVersion + 1}, nil
+	}
+
+	existing.Name = name
+	applied, err := s.repo.UpdateCategoryIfVersion(existing, m.BaseVersion, false)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if !applied {
+		current, err := s.repo.FindCategoryByUUID(userID, m.UUID)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		return s.categoryConflict(current), nil
+	}
+
+	s.audit.Record(actor, userID, AuditUpdate, "category", existing.ID, before, existing)
+	return SyncResult{Status: SyncApplied, Version: m.BaseVersion + 1}, nil
+}
+
+func (s *SyncService) categoryConflict(c *models.Category) SyncResult {
+	return SyncResult{Status: SyncConflict, Version: c.Version, Server: SyncCategory{*c, c.DeletedAt.Valid}}
+}
+
+func (s *SyncService) applyTransaction(actor Actor, m SyncMutation) (SyncResult, error) {
+	userID := actor.UserID
+	if !utils.IsUUID(m.UUID) {
+		return SyncResult{Status: SyncRejected, Error: "uuid must be a valid UUID"}, nil
+	}
+
+	existing, err := s.repo.FindTransactionByUUID(userID, m.UUID)
+	notFound := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !notFound {
+		return SyncResult{}, err
+	}
+
+	if notFound && m.Op == "delete" {
+		return SyncResult{Status: SyncApplied}, nil
+	}
+
+	t := &models.Transaction{UserID: userID}
+	if m.Op != "delete" {
+		if msg := s.resolveTransactionData(userID, m.Data, t); msg != "" {
+			return SyncResult{Status: SyncRejected, Error: msg}, nil
+		}
+	}
+
+	if notFound {
+		t.UUID = m.UUID
+		// The same rules, payee linking and duplicate flagging as other creates
+		if err := s.trans.prepare(t); err != nil {
+			return SyncResult{}, err
+		}
+		created, err := s.repo.CreateTransaction(t)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if !created {
+			// Another request created it first
+			current, err := s.repo.FindTransactionByUUID(userID, m.UUID)
+			if err != nil {
+				return SyncResult{}, err
+			}
+			return s.transactionConflict(current), nil
+		}
+		s.trans.settled(nil, t)
+		s.audit.Record(actor, userID, AuditCreate, "transaction", t.ID, nil, t)
+		return SyncResult{Status: SyncApplied, Version: t.Version}, nil
+	}
+
+	if m.BaseVersion != existing.Version {
+		return s.transactionConflict(existing), nil
+	}
+	if existing.Status == TransactionReconciled {
+		return SyncResult{Status: SyncRejected, Version: existing.Version, Error: ErrTransactionLocked.Error()}, nil
+	}
+
+	t.ID = existing.ID
+	applied, err := s.repo.UpdateTransactionIfVersion(t, m.BaseVersion, m.Op == "delete")
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if !applied {
+		current, err := s.repo.FindTransactionByUUID(userID, m.UUID)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		return s.transactionConflict(current), nil
+	}
+
+	if m.Op == "delete" {
+		s.trans.settled(existing, nil)
+		s.audit.Record(actor, userID, AuditDelete, "transaction", existing.ID, existing, nil)
+	} else {
+		current, err := s.repo.FindTransactionByUUID(userID, m.UUID)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		s.trans.settled(existing, current)
+		s.audit.Record(actor, userID, AuditUpdate, "transaction", existing.ID, existing, current)
+	}
+	return SyncResult{Status: SyncApplied, Version: m.BaseVersion + 1}, nil
+}
+
+func (s *SyncService) transactionConflict(t *models.Transaction) SyncResult {
+	return SyncResult{Status: SyncConflict, Version: t.Version, Server: SyncTransaction{Transaction: *t, Deleted: t.DeletedAt.Valid}}
+}
+
+// resolveTransactionData validates the payload and copies it onto t, returning
+// a rejection message when the data is unusable.
+func (s *SyncService) resolveTransactionData(userID uint, data SyncData, t *models.Transaction) string {
+	if data.Type != "income" && data.Type != "expense" {
+		return "type must be income or expense"
+	}
+	if data.Amount <= 0 {
+		return "amount must be greater than zero"
+	}
+	if data.Date.IsZero() {
+		return "date is required"
+	}
+
+	categoryID := data.CategoryID
+	if data.CategoryUUID != "" {
+		cat, err := s.repo.FindCategoryByUUID(userID, data.CategoryUUID)
+		if err != nil || cat.DeletedAt.Valid {
+			return "category not found"
+		}
+		categoryID = cat.ID
+	}
+	if categoryID == 0 {
+		return "category is required"
+	}
+	cat, err := s.categories.GetForTransaction(categoryID, userID, data.Type)
+	if errors.Is(err, ErrCategoryKindMatch) {
+		return err.Error()
+	} else if err != nil {
+		return "category not found"
+	}
+	t.CategoryID = cat.ID
+
+	t.Type = data.Type
+	t.Amount = data.Amount
+	t.Description = data.Description
+	t.Date = data.Date
+	return ""
+}
+
+// Cursors are opaque to clients; they carry the newest change timestamp sent, at
+// most syncCursorLag before now, in microseconds.
+func parseCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+	micros, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return time.UnixMicro(micros), nil
+}
+
+func formatCursor(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/quickadd"
+	"github.com/antigravity/finance-tracker/repositories"
+	"github.com/antigravity/finance-tracker/telegram"
+)
+
+// telegramCodeTTL is how long a link code stays valid after the user requests it.
+const telegramCodeTTL = 10 * time.Minute
+
+// telegramCodeAlphabet leaves out characters that are easy to mistype (0/O, 1/I/L).
+const telegramCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// telegramPollTimeout is the long-poll wait in seconds.
+const telegramPollTimeout = 50
+
+var ErrTelegramNotLinked = errors.New("no Telegram chat is linked")
+
+const telegramHelp = `Send a transaction like "kopi 25k" or "gaji 8jt kemarin" to add it to your inbox; approve it in the app to count it.
+
+Commands:
+/today - today's transactions
+/month - this month's income, expenses and top categories
+/budget - this month's budgets
+/unlink - disconnect this chat`
+
+// TelegramLinkCode is returned to the user to send to the bot as /start <code>.
+type TelegramLinkCode struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// TelegramService runs the Telegram bot: it links chats to users and turns their
+// messages into transactions and summaries.
+type TelegramService struct {
+	client      *telegram.Client
+	repo        *repositories.TelegramRepository
+	trans       *TransactionService
+	budgets     *BudgetService
+	audit       *AuditService
+	secret      string
+	botUsername string
+	webhookURL  string
+}
+
+// NewTelegramServiceFromEnv returns nil if TELEGRAM_BOT_TOKEN is not set,
+// which disables the bot. Webhook mode (TELEGRAM_WEBHOOK_URL) also needs
+// TELEGRAM_WEBHOOK_SECRET; without it anyone could post updates as a linked
+// chat, so the bot stays off.
+func NewTelegramServiceFromEnv(
+	repo *repositories.TelegramRepository,
+	trans *TransactionService,
+	budgets *BudgetService,
+	audit *AuditService,
+) *TelegramService {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil
+	}
+	if os.Getenv("TELEGRAM_WEBHOOK_URL") != "" && os.Getenv("TELEGRAM_WEBHOOK_SECRET") == "" {
+		log.Printf("Warning: TELEGRAM_WEBHOOK_URL is set without TELEGRAM_WEBHOOK_SECRET; Telegram bot disabled")
+		return nil
+	}
+	return &TelegramService{
+		client:      telegram.NewClient(token, os.Getenv("TELEGRAM_API_URL")),
+		repo:        repo,
+		trans:       trans,
+		budgets:     budgets,
+		audit:       audit,
+		secret:      os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+		botUsername: strings.TrimPrefix(os.Getenv("TELEGRAM_BOT_USERNAME"), "@"),
+		webhookURL:  os.Getenv("TELEGRAM_WEBHOOK_URL"),
+	}
+}
+
+// WebhookMode reports whether updates arrive by webhook rather than long polling.
+func (s *TelegramService) WebhookMode() bool {
+	return s.webhookURL != ""
+}
+
+// WebhookSecret is the value Telegram must send in telegram.SecretHeader. It is
+// always set in webhook mode.
+func (s *TelegramService) WebhookSecret() string {
+	return s.secret
+}
+
+// Start registers the webhook when TELEGRAM_WEBHOOK_URL is set and otherwise
+// long-polls for updates until ctx is cancelled.
+func (s *TelegramService) Start(ctx context.Context) {
+	if s.webhookURL != "" {
+		if err := s.client.SetWebhook(ctx, s.webhookURL, s.secret); err != nil {
+			log.Printf("Warning: Failed to set Telegram webhook: %v", err)
+		}
+		return
+	}
+	if err := s.client.DeleteWebhook(ctx); err != nil {
+		log.Printf("Warning: Failed to delete Telegram webhook: %v", err)
+	}
+	s.client.Poll(ctx, telegramPollTimeout, func(u telegram.Update) {
+		s.HandleUpdate(ctx, u)
+	})
+}
+
+// CreateLinkCode issues a one-time code the user sends to the bot to link a chat.
+func (s *TelegramService) CreateLinkCode(userID uint) (*TelegramLinkCode, error) {
+	code, err := randomCode(8)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(telegramCodeTTL)
+	if err := s.repo.CreateCode(&models.TelegramLinkCode{Code: code, UserID: userID, ExpiresAt: expiresAt}); err != nil {
+		return nil, err
+	}
+
+	result := &TelegramLinkCode{Code: code, ExpiresAt: expiresAt}
+	if s.botUsername != "" {
+		result.URL = fmt.Sprintf("https://t.me/%s?start=%s", s.botUsername, code)
+	}
+	return result, nil
+}
+
+func (s *TelegramService) GetLink(userID uint) (*models.TelegramLink, error) {
+	link, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return nil, ErrTelegramNotLinked
+	}
+	return link, nil
+}
+
+func (s *TelegramService) Unlink(userID uint) error {
+	if _, err := s.GetLink(userID); err != nil {
+		return err
+	}
+	return s.repo.DeleteByUser(userID)
+}
+
+// HandleUpdate answers one incoming message. Failures are logged rather than
+// returned so a bad update never blocks the ones after it.
+func (s *TelegramService) HandleUpdate(ctx context.Context, u telegram.Update) {
+	if u.Message == nil || strings.TrimSpace(u.Message.Text) == "" {
+		return
+	}
+	reply := s.reply(u.Message)
+	if reply == "" {
+		return
+	}
+	if err := s.client.SendMessage(ctx, u.Message.Chat.ID, reply); err != nil {
+		log.Printf("Warning: Failed to send Telegram reply to chat %d: %v", u.Message.Chat.ID, err)
+	}
+}
+
+func (s *TelegramService) reply(msg *telegram.Message) string {
+	command, arg := parseCommand(msg.Text, s.botUsername)
+
+	// In a group every member could post as, and read the finances of, whoever
+	// linked it, so only private chats are linked or served.
+	if msg.Chat.Type != telegram.ChatPrivate {
+		if command == "" {
+			return ""
+		}
+		return "I only work in a private chat. Message me directly to link your account."
+	}
+
+	if command == "start" || command == "link" {
+		if arg == "" {
+			return "Open Settings in the app, create a Telegram link code and send it here as /start <code>."
+		}
+		return s.linkChat(msg, arg)
+	}
+
+	link, err := s.repo.FindByChat(msg.Chat.ID)
+	if err != nil {
+		return "This chat is not linked yet. Create a link code in the app and send /start <code>."
+	}
+	userID := link.UserID
+
+	switch command {
+	case "":
+		return s.quickAdd(userID, msg.Text)
+	case "today":
+		return s.today(userID)
+	case "month":
+		return s.month(userID)
+	case "budget":
+		return s.budget(userID)
+	case "unlink":
+		if err := s.repo.DeleteByChat(msg.Chat.ID); err != nil {
+			return "Failed to unlink this chat, please try again."
+		}
+		return "This chat is no longer linked."
+	case "help":
+		return telegramHelp
+	default:
+		return "Unknown command.\n\n" + telegramHelp
+	}
+}
+
+func (s *TelegramService) linkChat(msg *telegram.Message, code string) string {
+	linkCode, err := s.repo.ConsumeCode(strings.ToUpper(code))
+	if err != nil {
+		return "That code is invalid or has expired. Create a new one in the app."
+	}
+	link := &models.TelegramLink{UserID: linkCode.UserID, ChatID: msg.Chat.ID, Username: msg.Chat.Username}
+	if msg.From != nil && msg.From.Username != "" {
+		link.Username = msg.From.Username
+	}
+	if err := s.repo.Link(link); err != nil {
+		return "Failed to link this chat, please try again."
+	}
+	return "Linked! " + telegramHelp
+}
+
+func (s *TelegramService) quickAdd(userID uint, text string) string {
+	draft, err := s.trans.ParseQuick(userID, text)
+	if errors.Is(err, quickadd.ErrNoAmount) {
+		return `I couldn't find an amount. Try something like "kopi 25k" or /help.`
+	}
+	if err != nil {
+		return "Failed to read that transaction, please try again."
+	}
+
+	transaction := &models.Transaction{
+		UserID:      userID,
+		Type:        draft.Type,
+		Amount:      draft.Amount,
+		CategoryID:  draft.CategoryID,
+		Description: draft.Description,
+		Date:        draft.Date,
+		Status:      TransactionDraft,
+	}
+	if err := s.trans.Create(transaction); err != nil {
+		return "Failed to save the transaction, please try again."
+	}
+	s.audit.Record(Actor{UserID: userID, IP: "telegram"}, userID, AuditCreate, "transaction", transaction.ID, nil, transaction)
+
+	category := draft.CategoryName
+	if saved, err := s.trans.GetByID(transaction.ID, userID); err == nil && saved.CategoryName != "" {
+		category = saved.CategoryName
+	}
+	return fmt.Sprintf("Added to your inbox: %s %s, %s\n%s · %s", transaction.Type, formatRupiah(transaction.Amount),
+		transaction.Description, category, transaction.Date.Format("Mon 2 Jan"))
+}
+
+func (s *TelegramService) today(userID uint) string {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	transactions, err := s.trans.GetAll(userID, map[string]interface{}{
+		"start_date": start,
+		"end_date":   start.AddDate(0, 0, 1).Add(-time.Nanosecond),
+		"status":     "all",
+	})
+	if err != nil {
+		return "Failed to load today's transactions."
+	}
+	return formatDay(transactions)
+}
+
+func (s *TelegramService) month(userID uint) string {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now().In(loc)
+	dashboard, err := s.trans.GetDashboard(userID, int(now.Month()), now.Year(), 1)
+	if err != nil {
+		return "Failed to load this month's summary."
+	}
+	summary, _ := dashboard["summary"].(map[string]float64)
+	breakdown, _ := dashboard["category_breakdown"].([]map[string]interface{})
+	return formatMonth(now, summary, breakdown)
+}
+
+func (s *TelegramService) budget(userID uint) string {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now().In(loc)
+	data, err := s.budgets.GetMonth(userID, int(now.Month()), now.Year(), 0)
+	if err != nil {
+		return "Failed to load this month's budgets."
+	}
+
+	var lines []string
+	if data["mode"] == BudgetModeEnvelope {
+		lines = append(lines, "Ready to assign: "+formatRupiah(data["ready_to_assign"].(float64)))
+		for _, e := range data["envelopes"].([]Envelope) {
+			lines = append(lines, fmt.Sprintf("%s: %s available", e.CategoryName, formatRupiah(e.Available)))
+		}
+	} else {
+		for _, b := range data["categories"].([]map[string]interface{}) {
+			lines = append(lines, fmt.Sprintf("%s: %s of %s left", b["category_name"],
+				formatRupiah(b["remaining"].(float64)), formatRupiah(b["budgeted"].(float64))))
+		}
+	}
+	if len(lines) == 0 {
+		return "No budgets set for " + now.Format("January 2006") + "."
+	}
+	return "Budgets for " + now.Format("January 2006") + "\n" + strings.Join(lines, "\n")
+}
+
+// parseCommand splits "/cmd@bot arg" into the lowercase command and its argument.
+// Commands addressed to another bot, and text that is not a command, give "".
+func parseCommand(text string, botUsername string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", ""
+	}
+	fields := strings.SplitN(text[1:], " ", 2)
+	command := strings.ToLower(fields[0])
+	if at := strings.Index(command, "@"); at >= 0 {
+		if botUsername != "" && !strings.EqualFold(command[at+1:], botUsername) {
+			return "", ""
+		}
+		command = command[:at]
+	}
+	arg := ""
+	if len(fields) > 1 {
+		arg = strings.TrimSpace(fields[1])
+	}
+	return command, arg
+}
+
+// formatRupiah renders an amount the Indonesian way, e.g. Rp25.000 or -Rp1.250.000.
+func formatRupiah(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := fmt.Sprintf("%d", int64(math.Round(amount)))
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + "Rp" + b.String()
+}
+
+func formatDay(transactions []models.Transaction) string {
+	if len(transactions) == 0 {
+		return "No transactions today."
+	}
+	var income, expense float64
+	lines := []string{"Today"}
+	for _, t := range transactions {
+		sign := "-"
+		if t.Type == "income" {
+			sign = "+"
+		}
+		description := t.Description
+		if description == "" {
+			description = t.CategoryName
+		}
+		// Drafts are listed so the user sees what they sent, but not totalled
+		if t.Status == TransactionDraft {
+			description += " (draft)"
+		} else if t.Type == "income" {
+			income += t.Amount
+		} else {
+			expense += t.Amount
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s", sign, formatRupiah(t.Amount), description))
+	}
+	lines = append(lines, fmt.Sprintf("\nIncome %s · Expenses %s", formatRupiah(income), formatRupiah(expense)))
+	return strings.Join(lines, "\n")
+}
+
+// formatMonth summarizes a month and lists its five largest expense categories.
+func formatMonth(now time.Time, summary map[string]float64, breakdown []map[string]interface{}) string {
+	lines := []string{
+		now.Format("January 2006"),
+		"Income: " + formatRupiah(summary["income"]),
+		"Expenses: " + formatRupiah(summary["expense"]),
+		"Balance: " + formatRupiah(summary["income"]-summary["expense"]),
+	}
+	if len(breakdown) > 0 {
+		lines = append(lines, "", "Top spending")
+	}
+	for i, b := range breakdown {
+		if i == 5 {
+			break
+		}
+		total, _ := b["total"].(float64)
+		lines = append(lines, fmt.Sprintf("%s: %s", b["category_name"], formatRupiah(total)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func randomCode(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = telegramCodeAlphabet[int(b)%len(telegramCodeAlphabet)]
+	}
+	return string(buf), nil
+}
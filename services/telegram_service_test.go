@@ -0,0 +1,88 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestParseCommand(t *testing.T) {
+	cases := []struct {
+		text, command, arg string
+	}{
+		{"/start AB12CD34", "start", "AB12CD34"},
+		{"/Today", "today", ""},
+		{"/month@FinanceBot", "month", ""},
+		{"/budget@OtherBot", "", ""},
+		{"  /link  xyz ", "link", "xyz"},
+		{"kopi 25k", "", ""},
+	}
+	for _, c := range cases {
+		command, arg := parseCommand(c.text, "financebot")
+		if command != c.command || arg != c.arg {
+			t.Errorf("parseCommand(%q) = %q, %q, want %q, %q", c.text, command, arg, c.command, c.arg)
+		}
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	cases := map[float64]string{
+		0:        "Rp0",
+		950:      "Rp950",
+		25000:    "Rp25.000",
+		1250000:  "Rp1.250.000",
+		-8500.4:  "-Rp8.500",
+		999999.6: "Rp1.000.000",
+	}
+	for amount, want := range cases {
+		if got := formatRupiah(amount); got != want {
+			t.Errorf("formatRupiah(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestFormatMonthListsTopFive(t *testing.T) {
+	var breakdown []map[string]interface{}
+	for i, name := range []string{"Rent", "Food", "Transport", "Health", "Shopping", "Gift"} {
+		breakdown = append(breakdown, map[string]interface{}{"category_name": name, "total": float64(6-i) * 1000})
+	}
+	text := formatMonth(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		map[string]float64{"income": 10000, "expense": 21000}, breakdown)
+
+	for _, want := range []string{"March 2024", "Balance: -Rp11.000", "Rent: Rp6.000", "Shopping: Rp2.000"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Gift") {
+		t.Errorf("Expected only the top five categories, got:\n%s", text)
+	}
+}
+
+func TestRandomCodeUsesAlphabet(t *testing.T) {
+	code, err := randomCode(8)
+	if err != nil || len(code) != 8 {
+		t.Fatalf("randomCode(8) = %q, %v", code, err)
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(telegramCodeAlphabet, r) {
+			t.Errorf("Unexpected character %q in %q", r, code)
+		}
+	}
+}
+
+func TestFormatDayLeavesDraftsOutOfTotals(t *testing.T) {
+	text := formatDay([]models.Transaction{
+		{Type: "expense", Amount: 25000, Description: "kopi", Status: TransactionCleared},
+		{Type: "expense", Amount: 50000, Description: "makan", Status: TransactionDraft},
+		{Type: "income", Amount: 100000, CategoryName: "Gift", Status: TransactionCleared},
+	})
+
+	for _, want := range []string{"-Rp25.000 kopi", "-Rp50.000 makan (draft)", "+Rp100.000 Gift", "Income Rp100.000 · Expenses Rp25.000"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
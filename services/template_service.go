@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateName     = errors.New("name is required")
+	ErrTemplateType     = errors.New("type must be income or expense")
+	ErrTemplateAmount   = errors.New("amount must be greater than zero")
+)
+
+// TemplateOverrides changes a template's values for one transaction. Nil fields
+// keep the template's value; a nil Date means now.
+type TemplateOverrides struct {
+	Date        *time.Time
+	Amount      *float64
+	CategoryID  *uint
+	Description *string
+	Tags        *[]string
+	AccountID   *uint // 0 removes the account
+	Status      string
+}
+
+type TemplateService struct {
+	repo       *repositories.TemplateRepository
+	trans      *TransactionService
+	categories *CategoryService
+	accounts   *AccountService
+}
+
+func NewTemplateService(
+	repo *repositories.TemplateRepository,
+	trans *TransactionService,
+	categories *CategoryService,
+	accounts *AccountService,
+) *TemplateService {
+	return &TemplateService{repo, trans, categories, accounts}
+}
+
+// GetAll lists the user's templates, most used first.
+func (s *TemplateService) GetAll(userID uint) ([]models.TransactionTemplate, error) {
+	return s.repo.FindAll(userID)
+}
+
+func (s *TemplateService) GetByID(id uint, userID uint) (*models.TransactionTemplate, error) {
+	template, err := s.repo.FindByID(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (s *TemplateService) Create(template *models.TransactionTemplate) error {
+	if err := s.validate(template); err != nil {
+		return err
+	}
+	return s.repo.Create(template)
+}
+
+func (s *TemplateService) Update(template *models.TransactionTemplate) error {
+	existing, err := s.GetByID(template.ID, template.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(template); err != nil {
+		return err
+	}
+	if err := s.repo.Update(template); err != nil {
+		return err
+	}
+	template.UsageCount = existing.UsageCount
+	template.LastUsedAt = existing.LastUsedAt
+	template.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+func (s *TemplateService) Delete(id uint, userID uint) error {
+	if _, err := s.GetByID(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id, userID)
+}
+
+// Use creates a transaction from the template with the overrides applied and
+// counts the use.
+func (s *TemplateService) Use(id uint, userID uint, overrides TemplateOverrides) (*models.Transaction, error) {
+	template, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	t := fromTemplate(template, overrides, now)
+	if _, err := s.categories.GetForTransaction(t.CategoryID, userID, t.Type); err != nil {
+		return nil, err
+	}
+	if t.AccountID != nil {
+		if _, err := s.accounts.GetByID(*t.AccountID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if t.Amount <= 0 {
+		return nil, ErrTemplateAmount
+	}
+
+	if err := s.trans.Create(t); err != nil {
+		return nil, err
+	}
+	// The transaction is already saved, so a failure here only costs the template its ranking
+	if err := s.repo.RecordUse(template.ID, userID, now); err != nil {
+		log.Printf("Warning: Failed to record use of template %d: %v", template.ID, err)
+	}
+	return t, nil
+}
+
+func (s *TemplateService) validate(template *models.TransactionTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	template.Tags = normalizeTags(template.Tags)
+	if template.Name == "" {
+		return ErrTemplateName
+	}
+	if template.Type != "income" && template.Type != "expense" {
+		return ErrTemplateType
+	}
+	if template.Amount <= 0 {
+		return ErrTemplateAmount
+	}
+	if _, err := s.categories.GetForTransaction(template.CategoryID, template.UserID, template.Type); err != nil {
+		return err
+	}
+	if template.AccountID != nil && *template.AccountID == 0 {
+		template.AccountID = nil
+	}
+	if template.AccountID != nil {
+		if _, err := s.accounts.GetByID(*template.AccountID, template.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fromTemplate builds the transaction a template describes, with overrides applied.
+func fromTemplate(template *models.TransactionTemplate, overrides TemplateOverrides, now time.Time) *models.Transaction {
+	t := &models.Transaction{
+		UserID:      template.UserID,
+		Type:        template.Type,
+		Amount:      template.Amount,
+		CategoryID:  template.CategoryID,
+		Description: template.Description,
+		Tags:        append(models.StringList{}, template.Tags...),
+		AccountID:   template.AccountID,
+		Date:        now,
+		Status:      overrides.Status,
+	}
+	if overrides.Date != nil {
+		t.Date = *overrides.Date
+	}
+	if overrides.Amount != nil {
+		t.Amount = *overrides.Amount
+	}
+	if overrides.CategoryID != nil {
+		t.CategoryID = *overrides.CategoryID
+	}
+	if overrides.Description != nil {
+		t.Description = *overrides.Description
+	}
+	if overrides.Tags != nil {
+		t.Tags = *overrides.Tags
+	}
+	if overrides.AccountID != nil {
+		t.AccountID = overrides.AccountID
+		if *overrides.AccountID == 0 {
+			t.AccountID = nil
+		}
+	}
+	return t
+}
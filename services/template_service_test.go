@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestFromTemplate(t *testing.T) {
+	account := uint(3)
+	template := &models.TransactionTemplate{
+		UserID: 1, Type: "expense", CategoryID: 7, Amount: 35000,
+		Description: "Lunch", Tags: models.StringList{"work"}, AccountID: &account,
+	}
+	now := time.Date(2024, 3, 12, 12, 0, 0, 0, time.UTC)
+
+	plain := fromTemplate(template, TemplateOverrides{}, now)
+	if plain.Amount != 35000 || plain.CategoryID != 7 || plain.Description != "Lunch" || !plain.Date.Equal(now) || *plain.AccountID != 3 {
+		t.Errorf("Expected the template's values, got %+v", plain)
+	}
+
+	amount, noAccount := 42000.0, uint(0)
+	tags := []string{"team"}
+	edited := fromTemplate(template, TemplateOverrides{Amount: &amount, Tags: &tags, AccountID: &noAccount}, now)
+	if edited.Amount != 42000 || len(edited.Tags) != 1 || edited.Tags[0] != "team" || edited.AccountID != nil {
+		t.Errorf("Expected the overrides applied, got %+v", edited)
+	}
+	if len(template.Tags) != 1 || template.Tags[0] != "work" {
+		t.Errorf("Expected the template left unchanged, got %v", template.Tags)
+	}
+}
@@ -1,35 +1,573 @@
 package services
 
 import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/antigravity/finance-tracker/models"
+	"github.com/antigravity/finance-tracker/quickadd"
 	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+// Transaction statuses. Drafts wait in the inbox and are left out of totals until
+// approved; pending and cleared follow the bank; reconciled is set by reconciliation.
+const (
+	TransactionDraft      = "draft"
+	TransactionPending    = "pending"
+	TransactionCleared    = "cleared"
+	TransactionReconciled = "reconciled"
+)
+
+// Bulk actions accepted by TransactionService.Bulk.
+const (
+	BulkRecategorize  = "recategorize"
+	BulkRetag         = "retag"
+	BulkChangeDate    = "change_date"
+	BulkChangeAccount = "change_account"
+	BulkDelete        = "delete"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrVersionConflict     = errors.New("transaction was modified by another request")
+	ErrNotDraft            = errors.New("transaction is not a draft")
+	ErrTransactionLocked   = errors.New("transaction is reconciled; unlock it before changing it")
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo    *repositories.TransactionRepository
+	catRepo *repositories.CategoryRepository
+	alerts  *AlertService
+	rules   *RuleService
+	suggest *SuggestionService
+	payees  *PayeeService
+	dupes   *DuplicateService
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo}
+func NewTransactionService(
+	repo *repositories.TransactionRepository,
+	catRepo *repositories.CategoryRepository,
+	alerts *AlertService,
+	rules *RuleService,
+	suggest *SuggestionService,
+	payees *PayeeService,
+	dupes *DuplicateService,
+) *TransactionService {
+	return &TransactionService{repo, catRepo, alerts, rules, suggest, payees, dupes}
 }
 
+// Create runs the user's rules over t and links it to a payee before saving it.
+// A transaction that still has no category afterwards goes to Uncategorized.
+// Without a status it is cleared. A likely duplicate is saved but flagged.
 func (s *TransactionService) Create(t *models.Transaction) error {
-	return s.repo.Create(t)
+	if err := s.prepare(t); err != nil {
+		return err
+	}
+	if err := s.repo.Create(t); err != nil {
+		return err
+	}
+	s.settled(nil, t)
+	return nil
+}
+
+// prepare readies a new transaction for saving, as described on Create.
+func (s *TransactionService) prepare(t *models.Transaction) error {
+	if t.Status == "" {
+		t.Status = TransactionCleared
+	}
+	t.Tags = normalizeTags(t.Tags)
+	s.rules.Apply(t)
+	if err := s.payees.Apply(t); err != nil {
+		return err
+	}
+	if t.CategoryID == 0 {
+		fallback, err := s.catRepo.FindDefaultByName(UncategorizedCategory)
+		if err != nil {
+			return err
+		}
+		t.CategoryID = fallback.ID
+	}
+	s.dupes.Flag(t)
+	return nil
+}
+
+// settled updates suggestions and alerts after a write. Drafts are not final, so
+// they are treated as absent until approved.
+func (s *TransactionService) settled(before, after *models.Transaction) {
+	if before != nil && before.Status == TransactionDraft {
+		before = nil
+	}
+	if after != nil && after.Status == TransactionDraft {
+		after = nil
+	}
+	if before != nil || after != nil {
+		s.suggest.Observe(before, after)
+	}
+	if after != nil {
+		s.alerts.EvaluateTransaction(after)
+	}
+}
+
+func (s *TransactionService) GetByID(id uint, userID uint) (*models.Transaction, error) {
+	t, err := s.repo.FindByID(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTransactionNotFound
+	}
+	return t, err
 }
 
-func (s *TransactionService) Update(t *models.Transaction) error {
-	return s.repo.Update(t)
+// Update replaces every editable field of t. See Patch for expectedVersion.
+// An empty status keeps the current one.
+func (s *TransactionService) Update(t *models.Transaction, expectedVersion uint) error {
+	fields := map[string]interface{}{
+		"type":        t.Type,
+		"amount":      t.Amount,
+		"category_id": t.CategoryID,
+		"account_id":  t.AccountID,
+		"description": t.Description,
+		"payee":       t.Payee,
+		"tags":        []string(t.Tags),
+		"date":        t.Date,
+	}
+	if t.Status != "" {
+		fields["status"] = t.Status
+	}
+	updated, err := s.Patch(t.ID, t.UserID, fields, expectedVersion)
+	if err != nil {
+		return err
+	}
+	*t = *updated
+	return nil
+}
+
+// Patch writes only the given columns. A non-zero expectedVersion makes the
+// write conditional, returning ErrVersionConflict if the row has moved on.
+func (s *TransactionService) Patch(id uint, userID uint, fields map[string]interface{}, expectedVersion uint) (*models.Transaction, error) {
+	if len(fields) == 0 {
+		current, err := s.GetByID(id, userID)
+		if err == nil && expectedVersion > 0 && current.Version != expectedVersion {
+			return nil, ErrVersionConflict
+		}
+		return current, err
+	}
+
+	before, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if before.Status == TransactionReconciled {
+		return nil, ErrTransactionLocked
+	}
+	if expectedVersion > 0 && before.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	if tags, ok := fields["tags"].([]string); ok {
+		fields["tags"] = normalizeTags(tags)
+	}
+	// Resolved only once the patch is known to go ahead, since it may create the payee
+	if name, ok := fields["payee"].(string); ok {
+		payee, err := s.payees.Resolve(userID, name)
+		if err != nil {
+			return nil, err
+		}
+		fields["payee_id"] = nil
+		if payee != nil {
+			fields["payee_id"] = payee.ID
+			fields["payee"] = payee.Name
+		}
+	}
+
+	updated, err := s.repo.UpdateFields(id, userID, fields, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrVersionConflict
+	}
+
+	s.settled(before, current)
+	return current, nil
 }
 
+// Delete soft-deletes one of the user's transactions. Reconciled transactions
+// are refused with ErrTransactionLocked, checked by the delete itself.
 func (s *TransactionService) Delete(id uint, userID uint) error {
-	return s.repo.Delete(id, userID)
+	before, err := s.GetByID(id, userID)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.repo.Delete(id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// Reconciled, or deleted by someone else, since it was read
+		current, err := s.GetByID(id, userID)
+		if err != nil {
+			return err
+		}
+		if current.Status == TransactionReconciled {
+			return ErrTransactionLocked
+		}
+		return ErrTransactionNotFound
+	}
+	s.settled(before, nil)
+	return nil
+}
+
+// Unlock returns a reconciled transaction to cleared so it can be edited or
+// deleted again. Its reconciliation no longer covers it afterwards.
+func (s *TransactionService) Unlock(id uint, userID uint) (*models.Transaction, error) {
+	current, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != TransactionReconciled {
+		return current, nil
+	}
+	updated, err := s.repo.UpdateFields(id, userID, map[string]interface{}{"status": TransactionCleared}, current.Version)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrVersionConflict
+	}
+	return s.GetByID(id, userID)
+}
+
+// MergeDuplicate keeps one of two duplicate entries: keep gains the other's tags
+// and any payee, account or description it lacks, then the other is deleted.
+// It returns keep as saved.
+func (s *TransactionService) MergeDuplicate(keepID uint, removeID uint, userID uint) (*models.Transaction, error) {
+	if keepID == removeID {
+		return nil, ErrDuplicatePair
+	}
+	keep, err := s.GetByID(keepID, userID)
+	if err != nil {
+		return nil, err
+	}
+	remove, err := s.GetByID(removeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if remove.Status == TransactionReconciled {
+		return nil, ErrTransactionLocked
+	}
+
+	fields := map[string]interface{}{}
+	if keep.Payee == "" && remove.Payee != "" {
+		// Already resolved on remove, so it is copied rather than resolved again
+		fields["payee"] = remove.Payee
+		fields["payee_id"] = remove.PayeeID
+	}
+	if keep.AccountID == nil && remove.AccountID != nil {
+		fields["account_id"] = *remove.AccountID
+	}
+	if keep.Description == "" && remove.Description != "" {
+		fields["description"] = remove.Description
+	}
+	if tags := normalizeTags(append(append([]string{}, keep.Tags...), remove.Tags...)); len(tags) != len(keep.Tags) {
+		fields["tags"] = []string(tags)
+	}
+	if len(fields) > 0 && keep.Status == TransactionReconciled {
+		return nil, ErrTransactionLocked
+	}
+
+	// Links to remove, keep's own included, are cleared in the same transaction
+	err = s.repo.Merge(userID, keep.ID, keep.Version, remove.ID, remove.Version, fields)
+	if errors.Is(err, repositories.ErrVersionChanged) {
+		return nil, ErrVersionConflict
+	} else if err != nil {
+		return nil, err
+	}
+
+	merged, err := s.GetByID(keep.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		s.settled(keep, merged)
+	}
+	s.settled(remove, nil)
+	return merged, nil
+}
+
+// GetDrafts lists the user's drafts awaiting review, newest first.
+func (s *TransactionService) GetDrafts(userID uint) ([]models.Transaction, error) {
+	return s.repo.FindAll(userID, map[string]interface{}{"status": TransactionDraft})
+}
+
+// GetDraft returns a transaction only if it is still a draft.
+func (s *TransactionService) GetDraft(id uint, userID uint) (*models.Transaction, error) {
+	t, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != TransactionDraft {
+		return nil, ErrNotDraft
+	}
+	return t, nil
+}
+
+// ApproveDrafts moves drafts to status (pending or cleared) so they count in
+// totals. IDs that are missing or no longer drafts are skipped; the approved
+// transactions are returned.
+func (s *TransactionService) ApproveDrafts(ids []uint, userID uint, status string) ([]models.Transaction, error) {
+	approved := []models.Transaction{}
+	for _, id := range ids {
+		draft, err := s.GetDraft(id, userID)
+		if err != nil {
+			continue
+		}
+		current, err := s.Patch(id, userID, map[string]interface{}{"status": status}, draft.Version)
+		if errors.Is(err, ErrVersionConflict) {
+			continue
+		}
+		if err != nil {
+			return approved, err
+		}
+		approved = append(approved, *current)
+	}
+	return approved, nil
+}
+
+// RejectDrafts deletes drafts. IDs that are missing or no longer drafts are
+// skipped; the rejected drafts are returned.
+func (s *TransactionService) RejectDrafts(ids []uint, userID uint) ([]models.Transaction, error) {
+	rejected := []models.Transaction{}
+	for _, id := range ids {
+		draft, err := s.GetDraft(id, userID)
+		if err != nil {
+			continue
+		}
+		deleted, err := s.repo.Delete(id, userID)
+		if err != nil {
+			return rejected, err
+		}
+		if !deleted {
+			continue
+		}
+		rejected = append(rejected, *draft)
+	}
+	return rejected, nil
+}
+
+// BulkChange is one edit applied to many transactions. Only the fields for
+// Action are read.
+type BulkChange struct {
+	Action    string
+	Category  *models.Category // recategorize
+	Tags      []string         // retag
+	TagMode   string           // retag: add (default), remove or set
+	Date      time.Time        // change_date
+	AccountID *uint            // change_account; nil removes the account
+}
+
+// BulkResult summarizes a bulk change. Matched counts the selected transactions,
+// Affected those changed or deleted, Locked the reconciled ones left alone and
+// Conflicts those changed by another request while the bulk change ran.
+// Before and After hold the affected rows for auditing; After is empty for deletes.
+type BulkResult struct {
+	Action    string               `json:"action"`
+	Matched   int                  `json:"matched"`
+	Affected  int                  `json:"affected"`
+	Locked    int                  `json:"locked"`
+	Conflicts int                  `json:"conflicts"`
+	IDs       []uint               `json:"ids"`
+	Before    []models.Transaction `json:"-"`
+	After     []models.Transaction `json:"-"`
+}
+
+// Bulk applies change to the user's transactions in ids, or to everything
+// matching filter when ids is nil, in one database transaction. Every ID must
+// belong to the user. Rows the change would not alter are skipped.
+func (s *TransactionService) Bulk(userID uint, ids []uint, filter map[string]interface{}, change BulkChange) (*BulkResult, error) {
+	var rows []models.Transaction
+	var err error
+	if ids != nil {
+		rows, err = s.repo.FindByIDs(userID, ids)
+		if err == nil && len(rows) != len(uniqueIDs(ids)) {
+			err = ErrTransactionNotFound
+		}
+	} else {
+		rows, err = s.repo.FindAll(userID, filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{Action: change.Action, Matched: len(rows), IDs: []uint{}}
+	updates := map[uint]map[string]interface{}{}
+	var deletes []uint
+	tags := normalizeTags(change.Tags)
+	for i := range rows {
+		t := &rows[i]
+		if t.Status == TransactionReconciled {
+			result.Locked++
+			continue
+		}
+		fields := map[string]interface{}{}
+		switch change.Action {
+		case BulkRecategorize:
+			if !kindAllows(change.Category.Kind, t.Type) {
+				return nil, ErrCategoryKindMatch
+			}
+			if t.CategoryID != change.Category.ID {
+				fields["category_id"] = change.Category.ID
+			}
+		case BulkRetag:
+			if retagged := retag(t.Tags, tags, change.TagMode); !sameTags(retagged, t.Tags) {
+				fields["tags"] = retagged
+			}
+		case BulkChangeDate:
+			if !t.Date.Equal(change.Date) {
+				fields["date"] = change.Date
+			}
+		case BulkChangeAccount:
+			if !sameAccount(t.AccountID, change.AccountID) {
+				fields["account_id"] = change.AccountID
+			}
+		case BulkDelete:
+			deletes = append(deletes, t.ID)
+		default:
+			return nil, errors.New("unknown bulk action")
+		}
+		if len(fields) > 0 {
+			updates[t.ID] = fields
+		}
+		if len(fields) > 0 || change.Action == BulkDelete {
+			result.IDs = append(result.IDs, t.ID)
+			result.Before = append(result.Before, *t)
+		}
+	}
+	result.Affected = len(result.IDs)
+	if result.Affected == 0 {
+		return result, nil
+	}
+
+	versions := make(map[uint]uint, len(result.Before))
+	for _, t := range result.Before {
+		versions[t.ID] = t.Version
+	}
+	skipped, err := s.repo.BulkWrite(userID, versions, updates, deletes)
+	if err != nil {
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		result.dropSkipped(skipped)
+	}
+	if change.Action == BulkDelete {
+		for i := range result.Before {
+			s.settled(&result.Before[i], nil)
+		}
+		return result, nil
+	}
+
+	after, err := s.repo.FindByIDs(userID, result.IDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Transaction, len(after))
+	for _, t := range after {
+		byID[t.ID] = t
+	}
+	for i := range result.Before {
+		current := byID[result.Before[i].ID]
+		result.After = append(result.After, current)
+		s.settled(&result.Before[i], &current)
+	}
+	return result, nil
+}
+
+// dropSkipped leaves rows the bulk write skipped out of the result and counts
+// them as conflicts.
+func (r *BulkResult) dropSkipped(skipped []uint) {
+	gone := make(map[uint]bool, len(skipped))
+	for _, id := range skipped {
+		gone[id] = true
+	}
+	ids, before := r.IDs[:0], r.Before[:0]
+	for _, t := range r.Before {
+		if !gone[t.ID] {
+			ids = append(ids, t.ID)
+			before = append(before, t)
+		}
+	}
+	r.IDs, r.Before = ids, before
+	r.Conflicts = len(skipped)
+	r.Affected = len(ids)
+}
+
+// retag adds tags to current, removes them from it, or replaces it.
+func retag(current models.StringList, tags models.StringList, mode string) models.StringList {
+	switch mode {
+	case "set":
+		return tags
+	case "remove":
+		drop := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			drop[tag] = true
+		}
+		kept := models.StringList{}
+		for _, tag := range current {
+			if !drop[tag] {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	default:
+		return normalizeTags(append(append([]string{}, current...), tags...))
+	}
+}
+
+func sameTags(a, b models.StringList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameAccount(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func uniqueIDs(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// SuggestCategories ranks likely categories for a transaction being entered.
+func (s *TransactionService) SuggestCategories(userID uint, description string, amount float64, txType string) ([]CategorySuggestion, error) {
+	return s.suggest.Suggest(userID, description, amount, txType)
 }
 
 func (s *TransactionService) GetAll(userID uint, filter map[string]interface{}) ([]models.Transaction, error) {
 	return s.repo.FindAll(userID, filter)
 }
 
-func (s *TransactionService) GetDashboard(userID uint, month int, year int) (map[string]interface{}, error) {
+// GetDashboard builds the dashboard for a month. A level above 0 rolls the category
+// breakdown up to parent categories at that depth (1 is top-level).
+func (s *TransactionService) GetDashboard(userID uint, month int, year int, level int) (map[string]interface{}, error) {
 	summary, err := s.repo.GetSummary(userID, month, year)
 	if err != nil {
 		return nil, err
@@ -54,11 +592,23 @@ func (s *TransactionService) GetDashboard(userID uint, month int, year int) (map
 		return nil, err
 	}
 
-	// Get category breakdown for the filtered month
-	breakdown, err := s.repo.GetCategoryBreakdown(userID, month, year)
+	// Get expense and income breakdowns for the filtered month
+	breakdown, err := s.repo.GetCategoryBreakdown(userID, month, year, "expense")
+	if err != nil {
+		return nil, err
+	}
+	incomeBreakdown, err := s.repo.GetCategoryBreakdown(userID, month, year, "income")
 	if err != nil {
 		return nil, err
 	}
+	if level > 0 {
+		categories, err := s.catRepo.FindAll(userID)
+		if err != nil {
+			return nil, err
+		}
+		breakdown = rollUpBreakdown(breakdown, categories, level)
+		incomeBreakdown = rollUpBreakdown(incomeBreakdown, categories, level)
+	}
 
 	return map[string]interface{}{
 		"summary": map[string]float64{
@@ -69,5 +619,112 @@ func (s *TransactionService) GetDashboard(userID uint, month int, year int) (map
 		"recent_transactions": lastTransactions,
 		"time_series":         timeSeries,
 		"category_breakdown":  breakdown,
+		"income_breakdown":    incomeBreakdown,
 	}, nil
 }
+
+func rollUpBreakdown(breakdown []map[string]interface{}, categories []models.Category, level int) []map[string]interface{} {
+	target := rollupMap(categories, level)
+	names := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	rolled := []map[string]interface{}{}
+	index := make(map[uint]int)
+	for _, row := range breakdown {
+		categoryID, _ := row["category_id"].(uint)
+		total, _ := row["total"].(float64)
+		name := row["category_name"]
+		if parentID, ok := target[categoryID]; ok && parentID != categoryID {
+			categoryID, name = parentID, names[parentID]
+		}
+
+		if i, ok := index[categoryID]; ok {
+			rolled[i]["total"] = rolled[i]["total"].(float64) + total
+			continue
+		}
+		index[categoryID] = len(rolled)
+		rolled = append(rolled, map[string]interface{}{
+			"category_id":   categoryID,
+			"category_name": name,
+			"total":         total,
+		})
+	}
+
+	sort.SliceStable(rolled, func(i, j int) bool {
+		return rolled[i]["total"].(float64) > rolled[j]["total"].(float64)
+	})
+	return rolled
+}
+
+// quickSuggestionConfidence is how sure the suggestion model must be before quick-add
+// uses its pick; below that the category is left to rules or Uncategorized.
+const quickSuggestionConfidence = 0.5
+
+// QuickDraft is a transaction parsed from quick-add text. CategorySource says how the
+// category was picked: "name" (the text names one), "keyword", "suggestion", or empty
+// when none was found.
+type QuickDraft struct {
+	Type           string    `json:"type"`
+	Amount         float64   `json:"amount"`
+	Date           time.Time `json:"date"`
+	Description    string    `json:"description"`
+	CategoryID     uint      `json:"category_id"`
+	CategoryName   string    `json:"category_name"`
+	CategorySource string    `json:"category_source"`
+}
+
+// ParseQuick turns text like "kopi 25k kemarin" into a draft transaction, matching
+// category hints against the categories the user can pick for that type.
+func (s *TransactionService) ParseQuick(userID uint, text string) (*QuickDraft, error) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	parsed, err := quickadd.Parse(text, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &QuickDraft{
+		Type:        parsed.Type,
+		Amount:      parsed.Amount,
+		Date:        parsed.Date,
+		Description: parsed.Description,
+	}
+
+	categories, err := s.catRepo.FindVisible(userID, false, false, parsed.Type)
+	if err != nil {
+		return nil, err
+	}
+	if cat := matchCategoryName(categories, parsed.Words); cat != nil {
+		draft.CategoryID, draft.CategoryName, draft.CategorySource = cat.ID, cat.Name, "name"
+		return draft, nil
+	}
+	if parsed.CategoryHint != "" {
+		for _, c := range categories {
+			if strings.EqualFold(c.Name, parsed.CategoryHint) {
+				draft.CategoryID, draft.CategoryName, draft.CategorySource = c.ID, c.Name, "keyword"
+				return draft, nil
+			}
+		}
+	}
+
+	suggestions, err := s.suggest.Suggest(userID, parsed.Description, parsed.Amount, parsed.Type)
+	if err == nil && len(suggestions) > 0 && suggestions[0].Confidence >= quickSuggestionConfidence {
+		draft.CategoryID, draft.CategoryName, draft.CategorySource = suggestions[0].CategoryID, suggestions[0].CategoryName, "suggestion"
+	}
+	return draft, nil
+}
+
+// matchCategoryName returns the category whose name appears as whole words in the
+// text, preferring the longest name so "Food & Beverage" beats "Food".
+func matchCategoryName(categories []models.Category, words []string) *models.Category {
+	text := " " + strings.Join(words, " ") + " "
+	var best *models.Category
+	for i := range categories {
+		name := strings.ToLower(categories[i].Name)
+		if strings.Contains(text, " "+name+" ") && (best == nil || len(name) > len(best.Name)) {
+			best = &categories[i]
+		}
+	}
+	return best
+}
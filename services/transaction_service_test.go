@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/antigravity/finance-tracker/models"
+)
+
+func TestRetag(t *testing.T) {
+	current := models.StringList{"food", "work"}
+	cases := []struct {
+		mode string
+		tags models.StringList
+		want models.StringList
+	}{
+		{"", models.StringList{"travel", "food"}, models.StringList{"food", "work", "travel"}},
+		{"add", models.StringList{}, models.StringList{"food", "work"}},
+		{"remove", models.StringList{"work", "missing"}, models.StringList{"food"}},
+		{"set", models.StringList{"trip"}, models.StringList{"trip"}},
+	}
+	for _, tc := range cases {
+		if got := retag(current, tc.tags, tc.mode); !sameTags(got, tc.want) {
+			t.Errorf("retag(%v, %v, %q) = %v, want %v", current, tc.tags, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestSameAccount(t *testing.T) {
+	one, otherOne, two := uint(1), uint(1), uint(2)
+	if !sameAccount(nil, nil) || !sameAccount(&one, &otherOne) {
+		t.Error("Expected equal accounts to match")
+	}
+	if sameAccount(&one, nil) || sameAccount(&one, &two) {
+		t.Error("Expected different accounts not to match")
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/antigravity/finance-tracker/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTrashItemNotFound  = errors.New("item not found in trash")
+	ErrCategoryDeleted    = errors.New("the transaction's category is also deleted; restore it or choose another category")
+	ErrCategoryNameTaken  = errors.New("an active category with this name already exists")
+	ErrCategoryReferenced = errors.New("category is still used by transactions")
+)
+
+const defaultTrashRetentionDays = 30
+
+type TrashService struct {
+	repo       *repositories.TrashRepository
+	categories *CategoryService
+	retention  time.Duration
+}
+
+// NewTrashService reads TRASH_RETENTION_DAYS (default 30) for the scheduled purge.
+func NewTrashService(repo *repositories.TrashRepository, categories *CategoryService) *TrashService {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultTrashRetentionDays
+	}
+	return &TrashService{repo, categories, time.Duration(days) * 24 * time.Hour}
+}
+
+func (s *TrashService) GetAll(userID uint) (map[string]interface{}, error) {
+	transactions, err := s.repo.FindTransactions(userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.FindCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// DeletedAt is hidden from the model JSON, so expose it next to each item
+	trashedTransactions := make([]map[string]interface{}, 0, len(transactions))
+	for _, t := range transactions {
+		trashedTransactions = append(trashedTransactions, map[string]interface{}{
+			"item":       t,
+			"deleted_at": t.DeletedAt.Time,
+		})
+	}
+	trashedCategories := make([]map[string]interface{}, 0, len(categories))
+	for _, c := range categories {
+		trashedCategories = append(trashedCategories, map[string]interface{}{
+			"item":       c,
+			"deleted_at": c.DeletedAt.Time,
+		})
+	}
+
+	return map[string]interface{}{
+		"transactions":   trashedTransactions,
+		"categories":     trashedCategories,
+		"retention_days": int(s.retention.Hours() / 24),
+	}, nil
+}
+
+// RestoreTransaction brings a transaction back. If its category is deleted too, the caller
+// must either ask to restore the category as well or pass a replacement category.
+func (s *TrashService) RestoreTransaction(id uint, userID uint, restoreCategory bool, replacementID uint) error {
+	t, err := s.repo.FindTransaction(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTrashItemNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if replacementID != 0 {
+		if _, err := s.categories.GetForTransaction(replacementID, userID, t.Type); err != nil {
+			return err
+		}
+		return s.repo.RestoreTransaction(id, userID, replacementID)
+	}
+
+	categoryID := t.CategoryID
+	cat, err := s.repo.FindCategoryByID(categoryID)
+	if err != nil || (cat.UserID != nil && *cat.UserID != userID) {
+		return ErrCategoryNotFound
+	}
+	if !kindAllows(cat.Kind, t.Type) {
+		return ErrCategoryKindMatch
+	}
+	if cat.DeletedAt.Valid {
+		if !restoreCategory || replacementID != 0 {
+			return ErrCategoryDeleted
+		}
+		if err := s.RestoreCategory(cat.ID, userID); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.RestoreTransaction(id, userID, categoryID)
+}
+
+func (s *TrashService) RestoreCategory(id uint, userID uint) error {
+	c, err := s.repo.FindCategory(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTrashItemNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountActiveCategoriesByName(userID, c.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryNameTaken
+	}
+
+	return s.repo.RestoreCategory(id)
+}
+
+func (s *TrashService) PurgeTransaction(id uint, userID uint) error {
+	if _, err := s.repo.FindTransaction(id, userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTrashItemNotFound
+	} else if err != nil {
+		return err
+	}
+	return s.repo.PurgeTransaction(id, userID)
+}
+
+// PurgeCategory permanently deletes a trashed category. Categories still referenced by
+// any transaction, including trashed ones, are kept so history stays intact.
+func (s *TrashService) PurgeCategory(id uint, userID uint) error {
+	if _, err := s.repo.FindCategory(id, userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTrashItemNotFound
+	} else if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountTransactionsByCategory(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryReferenced
+	}
+	return s.repo.PurgeCategory(id, userID)
+}
+
+// PurgeExpired permanently removes items that have been in the trash longer than the retention period.
+func (s *TrashService) PurgeExpired() {
+	transactions, categories, err := s.repo.PurgeDeletedBefore(time.Now().Add(-s.retention))
+	if err != nil {
+		log.Printf("Warning: Failed to purge trash: %v", err)
+		return
+	}
+	if transactions > 0 || categories > 0 {
+		log.Printf("Purged %d transactions and %d categories from trash", transactions, categories)
+	}
+}
@@ -0,0 +1,171 @@
+// Package telegram is a small Telegram Bot API client covering what the bot needs:
+// receiving updates by long polling or webhook and replying with text messages.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public Bot API. Tests and self-hosted Bot API servers use another.
+const DefaultBaseURL = "https://api.telegram.org"
+
+// SecretHeader carries the secret_token given to setWebhook on every webhook request.
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+}
+
+type Chat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+}
+
+// ChatPrivate is the Chat.Type of a one-to-one chat with the bot.
+const ChatPrivate = "private"
+
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name"`
+}
+
+// APIError is an unsuccessful Bot API response.
+type APIError struct {
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a client for the bot token. An empty baseURL means DefaultBaseURL.
+func NewClient(token string, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		// Long polls hold the request open, so the timeout must outlast them
+		http: &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		ErrorCode   int             `json:"error_code"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("telegram: %s returned %s", method, resp.Status)
+	}
+	if !envelope.OK {
+		return &APIError{Code: envelope.ErrorCode, Description: envelope.Description}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+// GetUpdates waits up to timeout seconds for updates after offset.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout int) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	return c.call(ctx, "sendMessage", map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil)
+}
+
+// SetWebhook asks Telegram to push updates to url, sending secret in SecretHeader.
+func (c *Client) SetWebhook(ctx context.Context, url string, secret string) error {
+	params := map[string]interface{}{"url": url, "allowed_updates": []string{"message"}}
+	if secret != "" {
+		params["secret_token"] = secret
+	}
+	return c.call(ctx, "setWebhook", params, nil)
+}
+
+// DeleteWebhook switches the bot back to getUpdates; Telegram refuses long polling
+// while a webhook is set.
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	return c.call(ctx, "deleteWebhook", map[string]interface{}{}, nil)
+}
+
+// Poll long-polls for updates and passes each one to handle until ctx is cancelled.
+// Errors are logged and retried after a short pause.
+func (c *Client) Poll(ctx context.Context, timeout int, handle func(Update)) {
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := c.GetUpdates(ctx, offset, timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Warning: Telegram getUpdates failed: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			handle(u)
+			offset = u.UpdateID + 1
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBotAPI is a local stand-in for the Bot API. It serves queued updates to
+// getUpdates one at a time and records sendMessage calls.
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	updates []Update
+	offsets []int64
+	sent    []map[string]interface{}
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/botTOKEN/") {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+	var params map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&params)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.TrimPrefix(r.URL.Path, "/botTOKEN/") {
+	case "getUpdates":
+		offset := int64(params["offset"].(float64))
+		f.offsets = append(f.offsets, offset)
+		pending := []Update{}
+		for _, u := range f.updates {
+			if u.UpdateID >= offset {
+				pending = append(pending, u)
+				break
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": pending})
+	case "sendMessage":
+		if params["text"] == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 400, "description": "Bad Request: message text is empty"})
+			return
+		}
+		f.sent = append(f.sent, params)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": map[string]interface{}{"message_id": len(f.sent)}})
+	default:
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": true})
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	fake := &fakeBotAPI{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient("TOKEN", server.URL)
+	if err := client.SendMessage(context.Background(), 42, "Saved"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	if len(fake.sent) != 1 || fake.sent[0]["chat_id"].(float64) != 42 || fake.sent[0]["text"] != "Saved" {
+		t.Errorf("Unexpected sendMessage calls: %v", fake.sent)
+	}
+
+	err := client.SendMessage(context.Background(), 42, "")
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != 400 {
+		t.Errorf("Expected a 400 APIError, got %v", err)
+	}
+}
+
+func TestWrongTokenIsAPIError(t *testing.T) {
+	server := httptest.NewServer(&fakeBotAPI{})
+	defer server.Close()
+
+	err := NewClient("WRONG", server.URL).SendMessage(context.Background(), 1, "hi")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != 401 {
+		t.Errorf("Expected a 401 APIError, got %v", err)
+	}
+}
+
+func TestPollAdvancesOffset(t *testing.T) {
+	fake := &fakeBotAPI{updates: []Update{
+		{UpdateID: 7, Message: &Message{Chat: Chat{ID: 1}, Text: "kopi 25k"}},
+		{UpdateID: 8, Message: &Message{Chat: Chat{ID: 1}, Text: "/today"}},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received []string
+	NewClient("TOKEN", server.URL).Poll(ctx, 0, func(u Update) {
+		received = append(received, u.Message.Text)
+		if len(received) == 2 {
+			cancel()
+		}
+	})
+
+	if len(received) != 2 || received[0] != "kopi 25k" || received[1] != "/today" {
+		t.Fatalf("Expected both updates in order, got %v", received)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.offsets) != 2 || fake.offsets[0] != 0 || fake.offsets[1] != 8 {
+		t.Errorf("Expected polls at offsets [0 8], got %v", fake.offsets)
+	}
+}
@@ -40,3 +40,19 @@ func TestGenerateAndValidateToken(t *testing.T) {
 		t.Errorf("Expected user ID %d, got %d", userID, validatedID)
 	}
 }
+
+func TestNewUUID(t *testing.T) {
+	id := NewUUID()
+	if !IsUUID(id) {
+		t.Fatalf("NewUUID returned invalid UUID %q", id)
+	}
+	if id[14] != '4' {
+		t.Errorf("Expected version 4 UUID, got %q", id)
+	}
+	if NewUUID() == id {
+		t.Errorf("NewUUID returned the same value twice")
+	}
+	if IsUUID("not-a-uuid") {
+		t.Errorf("IsUUID accepted an invalid value")
+	}
+}
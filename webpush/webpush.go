@@ -0,0 +1,270 @@
+// Package webpush sends Web Push messages with VAPID authentication (RFC 8292)
+// and aes128gcm payload encryption (RFC 8291).
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrSubscriptionExpired is returned when the push service reports that the
+// subscription no longer exists (404 or 410) and should be removed.
+var ErrSubscriptionExpired = errors.New("push subscription has expired")
+
+const recordSize = 4096
+
+// Keys is a VAPID application server key pair, encoded as unpadded base64url
+// like the browser Push API expects.
+type Keys struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+// GenerateKeys creates a new P-256 VAPID key pair.
+func GenerateKeys() (*Keys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+	}, nil
+}
+
+// Subscription is the browser PushSubscription a message is delivered to.
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Options controls delivery of a single message.
+type Options struct {
+	TTL     int    // Seconds the push service should retain the message
+	Urgency string // very-low, low, normal or high
+	Topic   string
+}
+
+type Sender struct {
+	keys       *Keys
+	signingKey *ecdsa.PrivateKey
+	subject    string
+	client     *http.Client
+}
+
+// NewSender returns a sender signing requests with the given VAPID keys.
+// subject is a mailto: or https: contact URI for the push service operator.
+func NewSender(keys *Keys, subject string, client *http.Client) (*Sender, error) {
+	raw, err := decodeBase64(keys.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	pub := priv.PublicKey().Bytes()
+	signingKey := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Sender{keys, signingKey, subject, client}, nil
+}
+
+// Send encrypts payload for the subscription and posts it to the push service.
+func (s *Sender) Send(sub Subscription, payload []byte, opts Options) error {
+	body, err := Encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+
+	authHeader, err := s.vapidHeader(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = 24 * 60 * 60
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(ttl))
+	req.Header.Set("Authorization", authHeader)
+	if opts.Urgency != "" {
+		req.Header.Set("Urgency", opts.Urgency)
+	}
+	if opts.Topic != "" {
+		req.Header.Set("Topic", opts.Topic)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionExpired
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Sender) vapidHeader(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": s.subject,
+	})
+	signed, err := token.SignedString(s.signingKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, s.keys.PublicKey), nil
+}
+
+// Encrypt produces an aes128gcm body for the subscription as described in RFC 8291.
+func Encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	// A 16 byte tag, 1 byte delimiter and the payload must fit in one record.
+	if len(payload) > recordSize-17 {
+		return nil, errors.New("payload is too large")
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	cek, nonce, err := deriveKeys(ecdhSecret, authSecret, uaPublicBytes, asPublicBytes, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := append(append([]byte{}, payload...), 0x02) // Last record delimiter, no padding
+
+	header := make([]byte, 0, 21+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// deriveKeys runs the RFC 8291 key schedule and returns the content
+// encryption key and nonce for a message.
+func deriveKeys(ecdhSecret, authSecret, uaPublic, asPublic, salt []byte) ([]byte, []byte, error) {
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	prkKey, err := hkdf.Extract(sha256.New, ecdhSecret, authSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	ikm, err := hkdf.Expand(sha256.New, prkKey, string(keyInfo), 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decodeBase64 accepts both padded and unpadded base64url, which browsers and
+// libraries use interchangeably for subscription keys.
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	if b, err := base64.URLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
@@ -0,0 +1,159 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func b64(t *testing.T, s string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("Failed to decode %q: %v", s, err)
+	}
+	return b
+}
+
+// Key schedule example from RFC 8291 section 5.
+func TestDeriveKeysRFC8291(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(b64(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatalf("Failed to load as_private: %v", err)
+	}
+	uaPublicBytes := b64(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		t.Fatalf("Failed to load ua_public: %v", err)
+	}
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		t.Fatalf("ECDH failed: %v", err)
+	}
+	if !bytes.Equal(ecdhSecret, b64(t, "kyrL1jIIOHEzg3sM2ZWRHDRB62YACZhhSlknJ672kSs")) {
+		t.Fatalf("Unexpected ecdh_secret")
+	}
+
+	cek, nonce, err := deriveKeys(ecdhSecret, b64(t, "BTBZMqHH6r4Tts7J_aSIgg"), uaPublicBytes,
+		asPrivate.PublicKey().Bytes(), b64(t, "DGv6ra1nlYgDCS1FRnbzlw"))
+	if err != nil {
+		t.Fatalf("Failed to derive keys: %v", err)
+	}
+	if !bytes.Equal(cek, b64(t, "oIhVW04MRdy2XN9CiKLxTg")) {
+		t.Errorf("Unexpected CEK %s", base64.RawURLEncoding.EncodeToString(cek))
+	}
+	if !bytes.Equal(nonce, b64(t, "4h_95klXJ5E_qnoN")) {
+		t.Errorf("Unexpected NONCE %s", base64.RawURLEncoding.EncodeToString(nonce))
+	}
+}
+
+// decrypt reverses Encrypt the way a browser would, using the subscription's private key.
+func decrypt(t *testing.T, uaPrivate *ecdh.PrivateKey, authSecret, body []byte) []byte {
+	if len(body) < 21 {
+		t.Fatalf("Body too short: %d bytes", len(body))
+	}
+	salt := body[:16]
+	idLen := int(body[20])
+	asPublicBytes := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("Invalid keyid in header: %v", err)
+	}
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("ECDH failed: %v", err)
+	}
+
+	cek, nonce, err := deriveKeys(ecdhSecret, authSecret, uaPrivate.PublicKey().Bytes(), asPublicBytes, salt)
+	if err != nil {
+		t.Fatalf("Failed to derive keys: %v", err)
+	}
+	gcm, err := newGCM(cek)
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Failed to decrypt payload: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("Missing last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestSendToLocalPushService(t *testing.T) {
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate subscription key: %v", err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	keys, err := GenerateKeys()
+	if err != nil {
+		t.Fatalf("Failed to generate VAPID keys: %v", err)
+	}
+
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+			t.Errorf("Missing push headers: %v", r.Header)
+		}
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "vapid t=") || !strings.HasSuffix(auth, ", k="+keys.PublicKey) {
+			t.Errorf("Unexpected Authorization header: %s", auth)
+		}
+		tokenString := strings.TrimSuffix(strings.TrimPrefix(auth, "vapid t="), ", k="+keys.PublicKey)
+		sender, _ := NewSender(keys, "mailto:test@example.com", nil)
+		_, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+			return &sender.signingKey.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience("http://"+r.Host))
+		if err != nil {
+			t.Errorf("Invalid VAPID token: %v", err)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		received = decrypt(t, uaPrivate, authSecret, body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(keys, "mailto:test@example.com", server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+
+	sub := Subscription{
+		Endpoint: server.URL + "/push/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(authSecret),
+	}
+	payload := []byte(`{"title":"Budget 80% used"}`)
+	if err := sender.Send(sub, payload, Options{}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if !bytes.Equal(received, payload) {
+		t.Errorf("Expected payload %q, got %q", payload, received)
+	}
+
+	sub.Endpoint = server.URL + "/gone"
+	if err := sender.Send(sub, payload, Options{}); err != ErrSubscriptionExpired {
+		t.Errorf("Expected ErrSubscriptionExpired, got %v", err)
+	}
+}